@@ -41,6 +41,22 @@ func Benchmark(b *testing.B) {
 			}
 		})
 	}
+	// Getter with compiled path
+	for _, set := range benchmarkSet {
+		fPath := set.updatingVal.(string)
+		if j, e = ioutil.ReadFile(fPath); e != nil {
+			b.Fatal(e)
+		}
+		compiled := MustCompile(set.path...)
+		name := fmt.Sprintf("-%s", fPath[strings.LastIndex(fPath, "/")+1:])
+		b.Run("GetCompiled"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, e = Get(j, compiled); e != nil {
+					b.Fatal(e)
+				}
+			}
+		})
+	}
 	// Remove
 	for _, set := range benchmarkSet {
 		fPath := set.updatingVal.(string)
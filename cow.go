@@ -0,0 +1,124 @@
+package hapijson
+
+// The setters of this package may modify data right in place to avoid allocating, e.g. Set reuses the capacity
+// of data, Clear writes the zero of a number into data, Minify compacts data itself, so it's not safe to share
+// the same data among goroutines while setting it.
+// The functions below are the copy-on-write versions of them, data is never modified, the new data is
+// always a fresh buffer, calling the original ones is opting in to modifying in place.
+// Every function modifying data has one, but ApplyPatch and Editor, which never modify data.
+
+// Clone returns a copy of data, which is safe to be modified by the setters.
+// The copy has some spare capacity, so that setting it usually doesn't allocate again.
+func Clone(data []byte) []byte {
+	return append(make([]byte, 0, len(data)+len(data)/8+64), data...)
+}
+
+// SetCopy is Set without modifying data.
+func SetCopy(data []byte, val interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return Set(Clone(data), val, pathNodes...)
+}
+
+// UpsertCopy is Upsert without modifying data.
+func UpsertCopy(data []byte, val interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return Upsert(Clone(data), val, pathNodes...)
+}
+
+// MergeCopy is Merge without modifying data.
+func MergeCopy(data []byte, preserve bool, pathNodes []interface{}, vals ...interface{}) (newData []byte, e error) {
+	return Merge(Clone(data), preserve, pathNodes, vals...)
+}
+
+// AppendCopy is Append without modifying data.
+func AppendCopy(data []byte, pathNodes []interface{}, vals ...interface{}) (newData []byte, e error) {
+	return Append(Clone(data), pathNodes, vals...)
+}
+
+// InsertCopy is Insert without modifying data.
+func InsertCopy(data []byte, index int, vals []interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return Insert(Clone(data), index, vals, pathNodes...)
+}
+
+// RemoveCopy is Remove without modifying data.
+func RemoveCopy(data []byte, pathNodes ...interface{}) (newData []byte, e error) {
+	return Remove(Clone(data), pathNodes...)
+}
+
+// ClearCopy is Clear without modifying data.
+func ClearCopy(data []byte, pathNodes ...interface{}) (newData []byte, e error) {
+	return Clear(Clone(data), pathNodes...)
+}
+
+// IncrCopy is Incr without modifying data.
+func IncrCopy(data []byte, delta interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return Incr(Clone(data), delta, pathNodes...)
+}
+
+// DecrCopy is Decr without modifying data.
+func DecrCopy(data []byte, delta interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return Decr(Clone(data), delta, pathNodes...)
+}
+
+// MulCopy is Mul without modifying data.
+func MulCopy(data []byte, factor interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return Mul(Clone(data), factor, pathNodes...)
+}
+
+// DivCopy is Div without modifying data.
+func DivCopy(data []byte, divisor interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return Div(Clone(data), divisor, pathNodes...)
+}
+
+// SetRawCopy is SetRaw without modifying data.
+func SetRawCopy(data, raw []byte, validate bool, pathNodes ...interface{}) (newData []byte, e error) {
+	return SetRaw(Clone(data), raw, validate, pathNodes...)
+}
+
+// AppendRawCopy is AppendRaw without modifying data.
+func AppendRawCopy(data []byte, validate bool, pathNodes []interface{}, raws ...[]byte) (newData []byte, e error) {
+	return AppendRaw(Clone(data), validate, pathNodes, raws...)
+}
+
+// MergeRawCopy is MergeRaw without modifying data.
+func MergeRawCopy(data, raw []byte, preserve, validate bool, pathNodes ...interface{}) (newData []byte, e error) {
+	return MergeRaw(Clone(data), raw, preserve, validate, pathNodes...)
+}
+
+// DeepMergeCopy is DeepMerge without modifying data.
+func DeepMergeCopy(data []byte, opts MergeOptions, pathNodes []interface{}, vals ...interface{}) (newData []byte, e error) {
+	return DeepMerge(Clone(data), opts, pathNodes, vals...)
+}
+
+// MergePatchCopy is MergePatch without modifying data.
+func MergePatchCopy(data, patch []byte) (newData []byte, e error) {
+	return MergePatch(Clone(data), patch)
+}
+
+// MoveCopy is Move without modifying data.
+func MoveCopy(data []byte, from, to []interface{}) (newData []byte, e error) {
+	return Move(Clone(data), from, to)
+}
+
+// CopyCopy is Copy without modifying data.
+func CopyCopy(data []byte, from, to []interface{}) (newData []byte, e error) {
+	return Copy(Clone(data), from, to)
+}
+
+// RenameKeyCopy is RenameKey without modifying data.
+func RenameKeyCopy(data []byte, newName string, pathNodes ...interface{}) (newData []byte, e error) {
+	return RenameKey(Clone(data), newName, pathNodes...)
+}
+
+// SetPointerCopy is SetPointer without modifying data.
+func SetPointerCopy(data []byte, val interface{}, pointer string) (newData []byte, e error) {
+	return SetPointer(Clone(data), val, pointer)
+}
+
+// RemovePointerCopy is RemovePointer without modifying data.
+func RemovePointerCopy(data []byte, pointer string) (newData []byte, e error) {
+	return RemovePointer(Clone(data), pointer)
+}
+
+// MinifyCopy is Minify without modifying json.
+func MinifyCopy(json []byte) (minified []byte) {
+	return Minify(Clone(json))
+}
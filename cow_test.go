@@ -0,0 +1,73 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCopyOnWrite(t *testing.T) {
+	const origin = `{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT"], "retired": true}`
+	var testSet = []struct {
+		name   string
+		fn     func(data []byte) ([]byte, error)
+		expect string
+	}{
+		{"SetCopy", func(data []byte) ([]byte, error) { return SetCopy(data, "LeBron James", "name") },
+			`{"name": "LeBron James", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT"], "retired": true}`},
+		{"UpsertCopy", func(data []byte) ([]byte, error) { return UpsertCopy(data, 1, "draft", "pick") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT"], "retired": true,"draft":{"pick":1}}`},
+		{"MergeCopy", func(data []byte) ([]byte, error) { return MergeCopy(data, true, nil, "mvp", 5) },
+			`{"name": "LBJ", "mvp": [4,5], "ppg": 27.1, "teams": ["CAVS", "HEAT"], "retired": true}`},
+		{"AppendCopy", func(data []byte) ([]byte, error) { return AppendCopy(data, Path("teams"), "LAL") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT","LAL"], "retired": true}`},
+		{"InsertCopy", func(data []byte) ([]byte, error) { return InsertCopy(data, 0, []interface{}{"SVSM"}, "teams") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["SVSM","CAVS", "HEAT"], "retired": true}`},
+		{"RemoveCopy", func(data []byte) ([]byte, error) { return RemoveCopy(data, "teams", 1) },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS"], "retired": true}`},
+		{"ClearCopy", func(data []byte) ([]byte, error) { return ClearCopy(data, "ppg") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 0, "teams": ["CAVS", "HEAT"], "retired": true}`},
+		{"IncrCopy", func(data []byte) ([]byte, error) { return IncrCopy(data, 1, "mvp") },
+			`{"name": "LBJ", "mvp": 5, "ppg": 27.1, "teams": ["CAVS", "HEAT"], "retired": true}`},
+		{"DivCopy", func(data []byte) ([]byte, error) { return DivCopy(data, 2, "ppg") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 13.55, "teams": ["CAVS", "HEAT"], "retired": true}`},
+		{"SetRawCopy", func(data []byte) ([]byte, error) { return SetRawCopy(data, []byte(`["HEAT"]`), true, "teams") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["HEAT"], "retired": true}`},
+		{"AppendRawCopy", func(data []byte) ([]byte, error) { return AppendRawCopy(data, false, Path("teams"), []byte(`"LAL"`)) },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT","LAL"], "retired": true}`},
+		{"MergeRawCopy", func(data []byte) ([]byte, error) { return MergeRawCopy(data, []byte(`{"mvp": 5}`), false, false) },
+			`{"name": "LBJ", "mvp": 5, "ppg": 27.1, "teams": ["CAVS", "HEAT"], "retired": true}`},
+		{"DeepMergeCopy", func(data []byte) ([]byte, error) {
+			return DeepMergeCopy(data, MergeOptions{}, nil, "teams", []string{"LAL"})
+		}, `{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT","LAL"], "retired": true}`},
+		{"MergePatchCopy", func(data []byte) ([]byte, error) { return MergePatchCopy(data, []byte(`{"retired": null}`)) },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT"]}`},
+		{"MoveCopy", func(data []byte) ([]byte, error) { return MoveCopy(data, Path("mvp"), Path("teams", 0)) },
+			`{"name": "LBJ", "ppg": 27.1, "teams": [4, "HEAT"], "retired": true}`},
+		{"CopyCopy", func(data []byte) ([]byte, error) { return CopyCopy(data, Path("mvp"), Path("teams", 0)) },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": [4, "HEAT"], "retired": true}`},
+		{"RenameKeyCopy", func(data []byte) ([]byte, error) { return RenameKeyCopy(data, "MVP", "mvp") },
+			`{"name": "LBJ", "MVP": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT"], "retired": true}`},
+		{"SetPointerCopy", func(data []byte) ([]byte, error) { return SetPointerCopy(data, "LAL", "/teams/1") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "LAL"], "retired": true}`},
+		{"RemovePointerCopy", func(data []byte) ([]byte, error) { return RemovePointerCopy(data, "/retired") },
+			`{"name": "LBJ", "mvp": 4, "ppg": 27.1, "teams": ["CAVS", "HEAT"]}`},
+		{"MinifyCopy", func(data []byte) ([]byte, error) { return MinifyCopy(data), nil },
+			`{"name":"LBJ","mvp":4,"ppg":27.1,"teams":["CAVS","HEAT"],"retired":true}`},
+	}
+	for _, set := range testSet {
+		// with spare capacity, the setters would modify data in place.
+		data := append(make([]byte, 0, len(origin)*2), origin...)
+		newData, e := set.fn(data)
+		if e != nil {
+			t.Fatal(set.name, e)
+		} else if string(data[:cap(data)]) != origin+string(make([]byte, len(origin))) {
+			t.Fatalf("%s: data is modified: %s", set.name, data[:cap(data)])
+		} else if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%s: Expected %s but got %s", set.name, set.expect, got)
+			t.Fail()
+		}
+	}
+	if clone := Clone([]byte(origin)); string(clone) != origin || cap(clone) <= len(origin) {
+		t.Fatalf("Expected a copy with spare capacity but got %s of cap %d", clone, cap(clone))
+	}
+}
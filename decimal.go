@@ -0,0 +1,295 @@
+package hapijson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivScale is the number of digits after the decimal point that Div rounds a quotient to, half to even,
+// if the quotient can't be represented exactly in decimal, e.g. 1/3. The digits are counted as the quotient is
+// written, e.g. 1e2 / 3 is 0.3333333333333333e2.
+//
+// Set it before using this package, it's not safe to be changed while other goroutines are using it.
+var DivScale = 16
+
+// maxExponent is the largest absolute value of the exponent of a number that can be calculated.
+const maxExponent = 999999999
+
+// maxScaleGap is how many digits a number could be extended with when being calculated, e.g. for adding 1e-5000
+// to 1, or writing 1e5000 without exponent, larger gaps are rejected rather than making huge numbers.
+const maxScaleGap = 10000
+
+// arithmetic operators of calculate.
+const (
+	opAdd = '+'
+	opSub = '-'
+	opMul = '*'
+	opDiv = '/'
+)
+
+// decimal is an exact decimal number, its value is unscaled * 10^-scale, scale is negative for the numbers with
+// large exponents, e.g. 1e30 is 1 in scale -30.
+type decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// numberStyle is how a json number is written, the result of calculating on it is written in the same way.
+type numberStyle struct {
+	// frac is the number of digits after the decimal point of the mantissa.
+	frac int
+	// exponent is the exponent part as it is, e.g. "e3", "E+3", and exp is its value.
+	exponent []byte
+	exp      int
+}
+
+// parseDecimal parses a json number, or a number in the form of json number, into a decimal.
+func parseDecimal(number []byte) (d decimal, style numberStyle, e error) {
+	mantissa := number
+	for i, b := range number {
+		if b == 'e' || b == 'E' {
+			if style.exp, e = strconv.Atoi(string(number[i+1:])); e != nil {
+				return d, style, fmt.Errorf("%s is not a valid number", number)
+			} else if style.exp > maxExponent || style.exp < -maxExponent {
+				return d, style, fmt.Errorf("the exponent of %s is out of range", number)
+			}
+			mantissa, style.exponent = number[:i], number[i:]
+			break
+		}
+	}
+	digits := make([]byte, 0, len(mantissa))
+	for i, b := range mantissa {
+		if b == '.' {
+			style.frac = len(mantissa) - i - 1
+		} else {
+			digits = append(digits, b)
+		}
+	}
+	var ok bool
+	if d.unscaled, ok = new(big.Int).SetString(string(digits), 10); !ok {
+		return d, style, fmt.Errorf("%s is not a valid number", number)
+	}
+	d.scale = style.frac - style.exp // the exponent is kept in scale rather than being expanded.
+	return
+}
+
+// decimalOf converts operand into a decimal, operand could be any of the go integers and floats, or a number
+// in string or json.Number, which are exact.
+func decimalOf(operand interface{}) (d decimal, e error) {
+	var number string
+	switch v := operand.(type) {
+	case int, int64, uint, uint64, uint8, int8, uint16, int16, uint32, int32:
+		number = fmt.Sprintf("%d", v)
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return d, fmt.Errorf("%v is not a valid number", v)
+		}
+		number = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return d, fmt.Errorf("%v is not a valid number", v)
+		}
+		number = strconv.FormatFloat(v, 'g', -1, 64)
+	case string, json.Number:
+		if number = fmt.Sprint(v); number == "" || !validateNumber([]byte(number), 0, len(number)) {
+			return d, fmt.Errorf("%q is not a valid number", number)
+		}
+	case *big.Int:
+		return decimal{unscaled: new(big.Int).Set(v)}, nil
+	default:
+		return d, fmt.Errorf("Unsupported type %T, %v", operand, operand)
+	}
+	d, _, e = parseDecimal([]byte(number))
+	return
+}
+
+// calculate returns the result of number op operand, e.g. opAdd means number + operand,
+// the result is written in the style of number.
+func calculate(number []byte, op byte, operand interface{}) (result []byte, e error) {
+	var x, y decimal
+	var style numberStyle
+	if x, style, e = parseDecimal(number); e != nil {
+		return
+	} else if y, e = decimalOf(operand); e != nil {
+		return
+	}
+	switch op {
+	case opAdd, opSub:
+		if x.scale-y.scale > maxScaleGap || y.scale-x.scale > maxScaleGap {
+			return nil, fmt.Errorf("the exponents of %s and %v are too far apart", number, operand)
+		}
+		x, y = rescale(x, y.scale), rescale(y, x.scale)
+		if op == opAdd {
+			x.unscaled.Add(x.unscaled, y.unscaled)
+		} else {
+			x.unscaled.Sub(x.unscaled, y.unscaled)
+		}
+	case opMul:
+		x.unscaled.Mul(x.unscaled, y.unscaled)
+		x.scale += y.scale
+	case opDiv:
+		if y.unscaled.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if x, e = quotient(x, y, DivScale-style.exp); e != nil {
+			return nil, fmt.Errorf("the exponents of %s and %v are too far apart", number, operand)
+		}
+	}
+	if result = x.format(style); result == nil {
+		return nil, fmt.Errorf("the result of %s %c %v is too large to be written in the style of %s",
+			number, op, operand, number)
+	}
+	return
+}
+
+// rescale returns d with the scale no less than scale, the value is the same, the scales should not be further apart
+// than maxScaleGap.
+func rescale(d decimal, scale int) decimal {
+	if d.scale >= scale {
+		return d
+	}
+	return decimal{unscaled: new(big.Int).Mul(d.unscaled, pow10(scale-d.scale)), scale: scale}
+}
+
+// quotient returns x / y, it's exact if the quotient is a finite decimal, otherwise it's rounded to scale.
+// It fails if the digits needed are more than maxScaleGap.
+func quotient(x, y decimal, scale int) (q decimal, e error) {
+	r := new(big.Rat).SetFrac(x.unscaled, y.unscaled)
+	num, den := new(big.Int).Set(r.Num()), new(big.Int).Set(r.Denom())
+	// x / y is num / den * 10^(y.scale - x.scale), num / den is a finite decimal if den has no prime factors
+	// but 2 and 5, which needs the digits of the larger count of them.
+	digits, rest := 0, new(big.Int).Set(den)
+	for twos, fives, mod := 0, 0, new(big.Int); ; {
+		if mod.Mod(rest, big.NewInt(2)); mod.Sign() == 0 {
+			rest.Quo(rest, big.NewInt(2))
+			if twos++; twos > digits {
+				digits = twos
+			}
+		} else if mod.Mod(rest, big.NewInt(5)); mod.Sign() == 0 {
+			rest.Quo(rest, big.NewInt(5))
+			if fives++; fives > digits {
+				digits = fives
+			}
+		} else {
+			break
+		}
+	}
+	if rest.Cmp(big.NewInt(1)) != 0 { // the digits for the value rounded to scale.
+		digits = scale - x.scale + y.scale
+	}
+	if digits > maxScaleGap || digits < -maxScaleGap {
+		return q, fmt.Errorf("too many digits of the quotient")
+	} else if digits >= 0 {
+		num.Mul(num, pow10(digits))
+	} else {
+		den.Mul(den, pow10(-digits))
+	}
+	unscaled, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	// rounds half to even, the quotient is truncated toward zero.
+	if rem.Sign() != 0 {
+		half := new(big.Int).Abs(rem)
+		switch half.Lsh(half, 1).Cmp(den) {
+		case 1:
+			unscaled.Add(unscaled, big.NewInt(int64(num.Sign())))
+		case 0:
+			if unscaled.Bit(0) == 1 {
+				unscaled.Add(unscaled, big.NewInt(int64(num.Sign())))
+			}
+		}
+	}
+	return decimal{unscaled: unscaled, scale: digits + x.scale - y.scale}, nil
+}
+
+// cmp compares d and y, it returns -1 if d < y, 0 if d == y, or 1 if d > y. The numbers are ordered by the
+// positions of their most significant digits first, so they are rescaled only if the positions are the same,
+// which means their scales are not far apart.
+func (d decimal) cmp(y decimal) int {
+	ds, ys := d.unscaled.Sign(), y.unscaled.Sign()
+	if ds != ys || ds == 0 {
+		if ds < ys {
+			return -1
+		} else if ds > ys {
+			return 1
+		}
+		return 0
+	}
+	dPos := len(new(big.Int).Abs(d.unscaled).String()) - d.scale
+	yPos := len(new(big.Int).Abs(y.unscaled).String()) - y.scale
+	if dPos != yPos {
+		if dPos > yPos {
+			return ds
+		}
+		return -ds
+	}
+	x := d
+	d, y = rescale(x, y.scale), rescale(y, x.scale)
+	return d.unscaled.Cmp(y.unscaled)
+}
+
+// equal tells whether d and y are the same number, e.g. 1.50 and 15e-1, they are compared without the trailing
+// zeros rather than being rescaled, which could be huge for the numbers with the exponents far apart.
+func (d decimal) equal(y decimal) bool {
+	d, y = d.trimmed(), y.trimmed()
+	return d.scale == y.scale && d.unscaled.Cmp(y.unscaled) == 0
+}
+
+// trimmed returns d without the trailing zeros of unscaled, the value is the same, zero is 0 in scale 0.
+func (d decimal) trimmed() decimal {
+	if d.unscaled.Sign() == 0 {
+		return decimal{unscaled: new(big.Int), scale: 0}
+	}
+	unscaled, scale := new(big.Int).Set(d.unscaled), d.scale
+	ten, q, mod := big.NewInt(10), new(big.Int), new(big.Int)
+	for {
+		if q.QuoRem(unscaled, ten, mod); mod.Sign() != 0 {
+			return decimal{unscaled: unscaled, scale: scale}
+		}
+		unscaled, q = q, unscaled
+		scale--
+	}
+}
+
+// format writes d in style, the digits after the decimal point are no less than the style's, and the trailing
+// zeros beyond are trimmed. It returns nil if the mantissa needs more zeros than maxScaleGap, or more digits after
+// the decimal point than maxScaleGap beyond the style's, e.g. 1e20000 or 1e-20000 in the style of 1.
+func (d decimal) format(style numberStyle) []byte {
+	d = d.trimmed()
+	unscaled, scale := d.unscaled, d.scale+style.exp // the mantissa
+	if scale < -maxScaleGap || scale-style.frac > maxScaleGap {
+		return nil
+	} else if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	ten, mod := big.NewInt(10), new(big.Int)
+	for scale > style.frac && mod.Mod(unscaled, ten).Sign() == 0 {
+		unscaled.Quo(unscaled, ten)
+		scale--
+	}
+	if scale < style.frac {
+		unscaled.Mul(unscaled, pow10(style.frac-scale))
+		scale = style.frac
+	}
+
+	var j []byte
+	if unscaled.Sign() < 0 {
+		j = append(j, '-')
+	}
+	digits := unscaled.Abs(unscaled).String()
+	if len(digits) <= scale { // at least a 0 before the decimal point.
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	j = append(j, digits[:len(digits)-scale]...)
+	if scale > 0 {
+		j = append(append(j, '.'), digits[len(digits)-scale:]...)
+	}
+	return append(j, style.exponent...)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,82 @@
+package hapijson
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestCalculate(t *testing.T) {
+	type calc func(data []byte, operand interface{}, pathNodes ...interface{}) ([]byte, error)
+	var testSet = []struct {
+		fn              calc
+		number          string
+		operand         interface{}
+		expect, errInfo string
+	}{
+		{Incr, "0.1", 0.2, "0.3", ""},
+		{Incr, "0.1", "0.2", "0.3", ""},
+		{Incr, "18446744073709551615", uint64(1), "18446744073709551616", ""},
+		{Incr, "123456789012345678901234567890", int64(-1), "123456789012345678901234567889", ""},
+		{Incr, "1.50", 1, "2.50", ""},
+		{Incr, "1.50", "0.125", "1.625", ""},
+		{Incr, "1.5e3", 1, "1.501e3", ""},
+		{Incr, "15E+2", 0.5, "15.005E+2", ""},
+		{Incr, "-1", 1, "0", ""},
+		{Incr, "-0.5", json.Number("0.25"), "-0.25", ""},
+		{Incr, "1", big.NewInt(2), "3", ""},
+		{Decr, "0.3", 0.1, "0.2", ""},
+		{Decr, "1", 2, "-1", ""},
+		{Mul, "1.50", 2, "3.00", ""},
+		{Mul, "0.1", 0.1, "0.01", ""},
+		{Mul, "2e2", 3, "6e2", ""},
+		{Div, "1", 8, "0.125", ""},
+		{Div, "1", 3, "0.3333333333333333", ""},
+		{Div, "2", 3, "0.6666666666666667", ""},
+		{Div, "-5", 2, "-2.5", ""},
+		{Div, "10.0", 4, "2.5", ""},
+		{Div, "1e2", 3, "0.3333333333333333e2", ""},
+		{Div, "1.5e-3", 7, "0.2142857142857143e-3", ""},
+		{Div, "1e2", 8, "0.125e2", ""},
+		// the exponents are kept rather than being expanded.
+		{Mul, "1e999999999", 1, "1e999999999", ""},
+		{Incr, "1e30000000", "1e29999999", "1.1e30000000", ""},
+		{Mul, "2.0e-999999999", "1.5", "3.0e-999999999", ""},
+		{Mul, "1e-999999999", "1e999999999", "", "too large to be written"},
+		{Div, "1e999999999", "1e-999999999", "", "too large to be written"},
+		{Div, "1", "3e-20000", "", "too far apart"},
+		{Div, "1e-20000", 4, "0.25e-20000", ""},
+		{Incr, "1", "1e10000000", "", "too far apart"},
+		{Decr, "1e-10000000", 1, "", "too far apart"},
+		{Mul, "1", "1e10000000", "", "too large to be written"},
+		{Mul, "1", "1e-200000", "", "too large to be written"},
+		{Div, "1", "1e200000", "", "too large to be written"},
+		{Mul, "1", "1e-999999999", "", "too large to be written"},
+		{Mul, "1", "1e-20", "0.00000000000000000001", ""},
+		{Div, "-1", "1e+5", "-0.00001", ""},
+		{Incr, "1e1000000000", 1, "", "out of range"},
+		{Div, "1", 0, "", "division by zero"},
+		{Incr, "1", "1.2.3", "", "not a valid number"},
+		{Incr, "1", math.NaN(), "", "not a valid number"},
+		{Incr, "1", true, "", "Unsupported type"},
+		{Incr, `"1"`, 1, "", "not json number"},
+	}
+	for _, set := range testSet {
+		data := []byte(`{"n": ` + set.number + `}`)
+		newData, e := set.fn(data, set.operand, "n")
+		if set.errInfo != "" {
+			if e == nil || strings.Index(e.Error(), set.errInfo) == -1 {
+				t.Fatalf("%s, %v: Expected error %q but got %v", set.number, set.operand, set.errInfo, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.number, set.operand, e)
+		}
+		if got := strings.TrimRight(string(newData), " "); got != `{"n": `+set.expect+`}` {
+			t.Logf("%s, %v: Expected %s but got %s", set.number, set.operand, set.expect, got)
+			t.Fail()
+		}
+	}
+}
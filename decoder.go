@@ -0,0 +1,383 @@
+package hapijson
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// defaultWindowSize is the size of the window buffer of a Decoder by default.
+const defaultWindowSize = 64 * 1024
+
+// Decoder reads json documents from an io.Reader and answers queries like Get and ArrayEach without
+// reading the whole documents into memory, the values not on the path are skipped byte by byte,
+// only the value being got is buffered. e.g.
+//
+//	dec := NewDecoder(file)
+//	team, e := dec.Get("career", 0, "team")
+//
+// Every query consumes a whole top-level document, so the documents in a stream, e.g. `{...} {...}` or
+// newline-delimited json, could be queried one by one, io.EOF is returned when there is no more document.
+//
+// As the data can't be read back, the first one of the duplicated keys is used, DuplicateKeys is not applied,
+// and negative indexes are not supported.
+type Decoder struct {
+	r *bufio.Reader
+	// depth is how many objects and arrays the decoder is in.
+	depth int
+	// value is the buffer of the value being got.
+	value []byte
+	key   []byte
+	e     error
+}
+
+// NewDecoder returns a Decoder reads from r with a window buffer in the default size.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderSize(r, defaultWindowSize)
+}
+
+// NewDecoderSize returns a Decoder reads from r with a window buffer of size bytes.
+func NewDecoderSize(r io.Reader, size int) *Decoder {
+	return &Decoder{r: bufio.NewReaderSize(r, size)}
+}
+
+// Get gets val from the last node of the pathNodes in the next document, see Get().
+func (d *Decoder) Get(pathNodes ...interface{}) (val interface{}, e error) {
+	var start, end int
+	var vtype valType
+	e = d.query(pathNodes, func() (e error) {
+		if _, e = d.readValue(true); e != nil {
+			return
+		}
+		if start, end, _, vtype, e = path(d.value, 0); e == nil {
+			val, e = fromJSON(d.value, start, end, vtype)
+		}
+		return
+	})
+	return
+}
+
+// ArrayEach calls fn with every element of the last node of the pathNodes in the next document which must
+// be an array, see ArrayEach(). Only one element is buffered at a time, value is valid until fn returns.
+func (d *Decoder) ArrayEach(fn func(index int, value []byte, kind Kind) error, pathNodes ...interface{}) (e error) {
+	return d.query(pathNodes, func() (e error) {
+		if b, e := d.peek(); e != nil {
+			return e
+		} else if b != '[' {
+			return genNotTypeError("not json array", pathNodes)
+		}
+		d.r.ReadByte()
+		d.depth++
+		var b byte
+		for index := 0; ; index++ {
+			if b, e = d.peek(); e != nil {
+				return
+			} else if b == ']' && index == 0 {
+				break
+			}
+			var vtype valType
+			if vtype, e = d.readValue(true); e != nil {
+				return
+			}
+			if e = fn(index, d.value, kindOf(vtype)); e != nil {
+				if e == ErrStop {
+					e = nil
+				}
+				return
+			}
+			if b, e = d.next(',', ']'); e != nil || b == ']' {
+				break
+			}
+			d.r.ReadByte()
+		}
+		if e == nil {
+			d.r.ReadByte() // the ']'
+			d.depth--
+		}
+		return
+	})
+}
+
+// query walks to the last node of the pathNodes in the next document and calls fn, then skips the rest of
+// the document.
+func (d *Decoder) query(pathNodes []interface{}, fn func() error) (e error) {
+	if d.e != nil {
+		return d.e
+	}
+	if pathNodes, e = pathNodesOf(pathNodes); e != nil {
+		return
+	}
+	if _, e = d.peek(); e == io.EOF {
+		d.e = e
+		return
+	} else if e == nil {
+		if e = d.walk(pathNodes); e == nil {
+			e = fn()
+		}
+	}
+	// the rest of the document must be skipped whatever the error of the query is, so the next document
+	// could be read.
+	if err := d.skipRest(); err != nil {
+		e = err
+	}
+	return
+}
+
+// walk walks to the last node of the pathNodes.
+func (d *Decoder) walk(pathNodes []interface{}) (e error) {
+	var b byte
+	for i, what := range pathNodes {
+		if b, e = d.peek(); e != nil {
+			return
+		}
+		if token, ok := what.(refToken); ok {
+			if what, e = token.node(b); e != nil {
+				return
+			}
+		} else if ck, ok := what.(compiledKey); ok {
+			what = ck.key
+		}
+		switch node := what.(type) {
+		case string:
+			if b != '{' {
+				return fmt.Errorf("the value of %q is not a json object", node)
+			}
+			e = d.findKey(i, node)
+		case int:
+			if b != '[' {
+				return fmt.Errorf("the value of %v is not a json array", node)
+			} else if node < 0 {
+				return fmt.Errorf("Error at No.%d in arguments: negative index %d is not supported", i+1, node)
+			}
+			e = d.findIndex(i, node)
+		default:
+			e = fmt.Errorf("Unsupported type %T, %v", what, what)
+		}
+		if e != nil {
+			return
+		}
+	}
+	return
+}
+
+// findKey reads the object until the key is found, the object begins at the next byte.
+func (d *Decoder) findKey(i int, key string) (e error) {
+	d.r.ReadByte() // the '{'
+	d.depth++
+	var b byte
+	for {
+		if b, e = d.peek(); e != nil {
+			return
+		} else if b == '}' {
+			break
+		} else if b != '"' {
+			return ErrInvalidJSONPayload
+		}
+		d.key = d.key[:0]
+		if e = d.readString(&d.key); e != nil {
+			return
+		}
+		var current string
+		if bytes.IndexByte(d.key, '\\') == -1 {
+			current = string(d.key[1 : len(d.key)-1])
+		} else if current, _, e = unescapeString(d.key, 1); e != nil {
+			return
+		}
+		if _, e = d.next(':'); e != nil {
+			return
+		}
+		d.r.ReadByte()
+		if current == key {
+			return
+		} else if _, e = d.readValue(false); e != nil {
+			return
+		} else if b, e = d.next(',', '}'); e != nil || b == '}' {
+			break
+		}
+		d.r.ReadByte()
+	}
+	if e == nil {
+		e = fmt.Errorf(`Error at No.%d in arguments: key %q is not found`, i+1, key)
+	}
+	return
+}
+
+// findIndex reads the array until the element at index, the array begins at the next byte.
+func (d *Decoder) findIndex(i, index int) (e error) {
+	d.r.ReadByte() // the '['
+	d.depth++
+	var b byte
+	var aryLength int
+	if b, e = d.peek(); e != nil || b == ']' {
+		goto done
+	}
+	for ; ; aryLength++ {
+		if aryLength == index {
+			return
+		} else if _, e = d.readValue(false); e != nil {
+			return
+		} else if b, e = d.next(',', ']'); e != nil || b == ']' {
+			aryLength++
+			break
+		}
+		d.r.ReadByte()
+	}
+done:
+	if e == nil {
+		e = fmt.Errorf(`Error at No.%d in arguments: index %d out of range, the len is %d`, i+1, index, aryLength)
+	}
+	return
+}
+
+// skipRest skips the rest of the document.
+func (d *Decoder) skipRest() (e error) {
+	for d.depth > 0 {
+		var b byte
+		if b, e = d.peek(); e != nil {
+			break
+		}
+		switch b {
+		case '}', ']':
+			d.r.ReadByte()
+			d.depth--
+		case ',', ':':
+			d.r.ReadByte()
+		default:
+			_, e = d.readValue(false)
+		}
+		if e != nil {
+			break
+		}
+	}
+	if e == nil && d.depth == 0 {
+		return
+	}
+	if e == io.EOF {
+		e = ErrInvalidJSONPayload
+	}
+	d.e = e
+	return
+}
+
+// readValue reads a value, it's kept in d.value if capture is true.
+func (d *Decoder) readValue(capture bool) (vtype valType, e error) {
+	var b byte
+	if b, e = d.peek(); e != nil {
+		return
+	}
+	var buf *[]byte
+	if capture {
+		d.value = d.value[:0]
+		buf = &d.value
+	}
+	switch b {
+	case '"':
+		return valString, d.readString(buf)
+	case '{', '[':
+		vtype = valObject
+		if b == '[' {
+			vtype = valArray
+		}
+		var depth int
+		for {
+			if b, e = d.r.ReadByte(); e != nil {
+				break
+			}
+			switch b {
+			case '"':
+				d.r.UnreadByte()
+				if e = d.readString(buf); e != nil {
+					return
+				}
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+			if buf != nil {
+				*buf = append(*buf, b)
+			}
+			if depth == 0 {
+				return
+			}
+		}
+	case 't', 'f', 'n', '-', '.', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		for {
+			if b, e = d.r.ReadByte(); e != nil {
+				break
+			}
+			if b == ',' || b == '}' || b == ']' || b == ' ' || b == '\t' || b == '\n' || b == '\r' {
+				d.r.UnreadByte()
+				break
+			}
+			if buf != nil {
+				*buf = append(*buf, b)
+			}
+		}
+		if e == io.EOF && d.depth == 0 {
+			e = nil // the document is a number, true, false or null.
+		}
+		if e == nil && buf != nil {
+			var ok bool
+			if _, vtype, ok = scanScalar(*buf, 0); !ok {
+				e = ErrInvalidJSONPayload
+			}
+		}
+		return
+	default:
+		return valUnknown, ErrInvalidJSONPayload
+	}
+	if e == io.EOF {
+		e = ErrInvalidJSONPayload
+	}
+	return
+}
+
+// readString reads a string including its quotes, it's appended to buf if buf is not nil.
+func (d *Decoder) readString(buf *[]byte) (e error) {
+	var b byte
+	var escaped bool
+	for i := 0; ; i++ {
+		if b, e = d.r.ReadByte(); e != nil {
+			if e == io.EOF {
+				e = ErrInvalidJSONPayload
+			}
+			return
+		}
+		if buf != nil {
+			*buf = append(*buf, b)
+		}
+		if escaped {
+			escaped = false
+		} else if b == '\\' {
+			escaped = true
+		} else if b == '"' && i > 0 {
+			return
+		}
+	}
+}
+
+// peek skips the white characters and returns the next byte without reading it.
+func (d *Decoder) peek() (b byte, e error) {
+	for {
+		if b, e = d.r.ReadByte(); e != nil {
+			return
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			d.r.UnreadByte()
+			return
+		}
+	}
+}
+
+// next peeks the next byte which must be one of expected.
+func (d *Decoder) next(expected ...byte) (b byte, e error) {
+	if b, e = d.peek(); e == io.EOF {
+		e = ErrInvalidJSONPayload
+	} else if e == nil && bytes.IndexByte(expected, b) == -1 {
+		e = ErrInvalidJSONPayload
+	}
+	return
+}
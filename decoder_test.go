@@ -0,0 +1,87 @@
+package hapijson
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{"title"}, expect: "Game of Thrones"},
+		{path: []interface{}{"reviews", 0, "review", 0, "vote"}, expect: "756/757"},
+		{path: []interface{}{"reviews", 1, "review", 3}, expect: 3.141592653},
+		{path: []interface{}{"reviews", 2, "user"}, expect: "Mary come here 👄"},
+		{path: []interface{}{PathExpr("ratings[1]['TV.com']")}, expect: "9/10"},
+		{path: []interface{}{Pointer("/relevant/Episodes/0/seasons/7")}, expect: 8},
+		{path: []interface{}{"ratings", 0}, expect: map[string]interface{}{"IMDB": 9.3}},
+		{path: []interface{}{"not exists"}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not found") == -1 }},
+		{path: []interface{}{"genre", 4}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		{path: []interface{}{"genre", -1}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not supported") == -1 }},
+		{path: []interface{}{"title", "x"}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not a json object") == -1 }},
+	}
+	// all the documents are in one stream, and the window is much smaller than a document.
+	var stream bytes.Buffer
+	for range testSet {
+		stream.Write(jsonGetSetData)
+		stream.WriteByte('\n')
+	}
+	dec := NewDecoderSize(&stream, 16)
+	for _, set := range testSet {
+		val, e := dec.Get(set.path...)
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatal(set.path, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.path, e)
+		}
+		if !reflect.DeepEqual(val, set.expect) {
+			t.Logf("Expected %#v but got %#v", set.expect, val)
+			t.Fail()
+		}
+	}
+	if _, e := dec.Get("title"); e != io.EOF {
+		t.Fatalf("Expected io.EOF but got %v", e)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{"career": [{"team": "CAVS"}, {"team": "HEAT"}, {"team": "LAL"}]} [] 23 "end"`))
+	var teams []string
+	if e := dec.ArrayEach(func(index int, value []byte, kind Kind) error {
+		if kind != KindObject || index != len(teams) {
+			t.Fatalf("Unexpected element %d: %s", index, value)
+		}
+		team, e := String(value, "team")
+		if teams = append(teams, team); team == "HEAT" {
+			return ErrStop
+		}
+		return e
+	}, "career"); e != nil {
+		t.Fatal(e)
+	} else if expect := []string{"CAVS", "HEAT"}; !reflect.DeepEqual(teams, expect) {
+		t.Fatalf("Expected %v but got %v", expect, teams)
+	}
+	if e := dec.ArrayEach(func(int, []byte, Kind) error {
+		t.Fatal("Unexpected element of empty array")
+		return nil
+	}); e != nil {
+		t.Fatal(e)
+	}
+	if val, e := dec.Get(); e != nil || val != 23 {
+		t.Fatalf("Expected 23 but got %v, %v", val, e)
+	} else if val, e = dec.Get(); e != nil || val != "end" {
+		t.Fatalf("Expected end but got %v, %v", val, e)
+	} else if _, e = dec.Get(); e != io.EOF {
+		t.Fatalf("Expected io.EOF but got %v", e)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{"a": [1, 2`))
+	if _, e := dec.Get("b"); e != ErrInvalidJSONPayload {
+		t.Fatalf("Expected %v but got %v", ErrInvalidJSONPayload, e)
+	} else if _, e = dec.Get(); e != ErrInvalidJSONPayload {
+		t.Fatalf("Expected %v again but got %v", ErrInvalidJSONPayload, e)
+	}
+}
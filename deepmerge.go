@@ -0,0 +1,243 @@
+package hapijson
+
+import (
+	"fmt"
+)
+
+// ScalarStrategy decides how DeepMerge merges a new value into an old value, when either of them is not
+// an object or an array, or they are in different types.
+type ScalarStrategy int8
+
+const (
+	// ScalarOverwrite overwrites the old value with the new one.
+	ScalarOverwrite ScalarStrategy = iota
+	// ScalarKeep keeps the old value.
+	ScalarKeep
+)
+
+// ArrayStrategy decides how DeepMerge merges a new array into an old array.
+type ArrayStrategy int8
+
+const (
+	// ArrayConcat appends the new elements to the old array.
+	ArrayConcat ArrayStrategy = iota
+	// ArrayReplace replaces the old array with the new one.
+	ArrayReplace
+	// ArrayMergeByIndex merges the new elements into the old ones at the same indexes,
+	// the rest of the new elements are appended.
+	ArrayMergeByIndex
+	// ArrayMergeByKey merges the new objects into the old objects which have the same value of the field
+	// MergeOptions.KeyField, the others are appended.
+	ArrayMergeByKey
+)
+
+// MergeOptions are the strategies of DeepMerge.
+type MergeOptions struct {
+	Scalars ScalarStrategy
+	Arrays  ArrayStrategy
+	// KeyField is the key of the field identifies an object in arrays, for ArrayMergeByKey.
+	KeyField string
+}
+
+// DeepMerge merges vals into the object of the last node of the pathNodes recursively, e.g. json:
+//
+//	{"db": {"host": "localhost", "port": 5432}, "tags": ["a"], "users": [{"id": 1, "name": "LBJ"}]}
+//
+// after being merged with vals:
+//
+//	{"db": {"port": 6432, "ssl": true}, "tags": ["b"], "users": [{"id": 1, "team": "LAL"}]}
+//
+// if opts is MergeOptions{Arrays: ArrayMergeByKey, KeyField: "id"}
+//
+//	{"db": {"host": "localhost", "port": 6432,"ssl":true}, "tags": ["a","b"], "users": [{"id": 1, "name": "LBJ","team":"LAL"}]}
+//
+// Objects are always merged recursively, arrays and the other values are merged by the strategies in opts.
+// vals are the same as Merge's, the new keys are added in the order of vals if they are in pairs.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json encoding, it doesn't do checking inside ...
+// See the Note part of Set().
+func DeepMerge(data []byte, opts MergeOptions, pathNodes []interface{}, vals ...interface{}) (newData []byte, e error) {
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valObject {
+		return nil, genNotTypeError("not json object", pathNodes)
+	}
+	var src []byte
+	if src, e = objectJSON(vals...); e != nil {
+		return
+	}
+	newData, _, _, e = deepMerge(data, location{start: start, end: end, vtype: vtype}, rootEndOf(data),
+		src, location{start: 0, end: len(src), vtype: valObject}, &opts)
+	return
+}
+
+// objectJSON makes an object from vals which are either a map[string]interface{} or pairs of keys and values,
+// see pairsOf.
+func objectJSON(vals ...interface{}) (j []byte, e error) {
+	var pairs []interface{}
+	if pairs, e = pairsOf(vals...); e != nil {
+		return
+	}
+	j = append(j, '{')
+	for i := 0; i < len(pairs); i += 2 {
+		var valJSON []byte
+		if valJSON, _, e = toJSON(pairs[i+1]); e != nil {
+			return
+		}
+		if i > 0 {
+			j = append(j, ',')
+		}
+		j = append(j, fmt.Sprintf(`"%s":`, escape(pairs[i].(string)))...)
+		j = append(j, valJSON...)
+	}
+	return append(j, '}'), nil
+}
+
+// deepMerge merges the value of src at s into the value of payload at old.
+func deepMerge(payload []byte, old location, rootEnd int, src []byte, s location, opts *MergeOptions) (newPayload []byte,
+	newEnd, newRootEnd int, e error) {
+
+	if old.vtype == valObject && s.vtype == valObject {
+		return deepMergeObject(payload, old, rootEnd, src, s, opts)
+	} else if old.vtype == valArray && s.vtype == valArray && opts.Arrays != ArrayReplace {
+		switch opts.Arrays {
+		case ArrayConcat:
+			return concatArray(payload, old, rootEnd, src, s)
+		case ArrayMergeByIndex, ArrayMergeByKey:
+			return deepMergeArray(payload, old, rootEnd, src, s, opts)
+		}
+	} else if opts.Scalars == ScalarKeep && (old.vtype != valArray || s.vtype != valArray) {
+		return payload, old.end, rootEnd, nil
+	}
+	newPayload, newEnd, newRootEnd = updatePayload(payload, src[s.start:s.end], old.start, old.end, rootEnd)
+	return
+}
+
+// deepMergeObject merges the keys of the object of src at s into the object of payload at old one by one.
+func deepMergeObject(payload []byte, old location, rootEnd int, src []byte, s location, opts *MergeOptions) (newPayload []byte,
+	newEnd, newRootEnd int, e error) {
+
+	var key string
+	var hasKey, next, found bool
+	var child, oldChild location
+	end, newValEnd := old.end, 0
+	for pos := s.start + 1; pos < s.end; pos++ {
+		if pos, key, hasKey, e = nextKey(src, pos, true); e != nil {
+			return
+		} else if !hasKey {
+			break
+		} else if pos, child.start, child.end, child.vtype, next, _, e = nextValue(src, pos); e != nil {
+			return
+		}
+		if oldChild, found, e = findKey(payload, old.start, key, ""); e != nil {
+			return
+		} else if !found {
+			j := []byte(fmt.Sprintf(`"%s":%s}`, escape(key), string(src[child.start:child.end])))
+			payload, end, rootEnd = appendJSON(payload, j, valObject, old.start, end, rootEnd)
+		} else if payload, newValEnd, rootEnd, e = deepMerge(payload, oldChild, rootEnd, src, child, opts); e != nil {
+			return
+		} else {
+			end += newValEnd - oldChild.end
+		}
+		if !next {
+			break
+		}
+	}
+	newPayload, newEnd, newRootEnd = payload, end, rootEnd
+	return
+}
+
+// concatArray appends the elements of the array of src at s to the array of payload at old.
+func concatArray(payload []byte, old location, rootEnd int, src []byte, s location) (newPayload []byte,
+	newEnd, newRootEnd int, e error) {
+
+	elements := trimWhites(src[s.start+1 : s.end-1])
+	if len(elements) == 0 {
+		return payload, old.end, rootEnd, nil
+	}
+	j := make([]byte, len(elements)+1)
+	copy(j, elements)
+	j[len(elements)] = ']'
+	newPayload, newEnd, newRootEnd = appendJSON(payload, j, valArray, old.start, old.end, rootEnd)
+	return
+}
+
+// deepMergeArray merges the elements of the array of src at s into the array of payload at old, by index or by
+// the key field.
+func deepMergeArray(payload []byte, old location, rootEnd int, src []byte, s location, opts *MergeOptions) (newPayload []byte,
+	newEnd, newRootEnd int, e error) {
+
+	var next, empty, found bool
+	var child, oldChild location
+	end, newValEnd := old.end, 0
+	// for ArrayMergeByIndex, the old array is walked along with the new one, oldPos is where its next element is.
+	oldPos, oldNext := old.start+1, true
+	for pos := s.start + 1; pos < s.end; pos++ {
+		if pos, child.start, child.end, child.vtype, next, empty, e = nextValue(src, pos); e != nil || empty {
+			break
+		}
+		if opts.Arrays == ArrayMergeByIndex {
+			// the old array may be shorter, the rest are appended.
+			if found = false; oldNext {
+				if oldPos, oldChild.start, oldChild.end, oldChild.vtype, oldNext, empty, e = nextValue(payload, oldPos); e != nil {
+					return
+				}
+				found, oldPos = !empty, oldPos+1
+			}
+		} else if oldChild, found, e = elementByKey(payload, old.start, src, child, opts.KeyField); e != nil {
+			return
+		}
+		if !found {
+			j := make([]byte, child.end-child.start+1)
+			copy(j, src[child.start:child.end])
+			j[len(j)-1] = ']'
+			payload, end, rootEnd = appendJSON(payload, j, valArray, old.start, end, rootEnd)
+		} else if payload, newValEnd, rootEnd, e = deepMerge(payload, oldChild, rootEnd, src, child, opts); e != nil {
+			return
+		} else {
+			end += newValEnd - oldChild.end
+			oldPos += newValEnd - oldChild.end
+		}
+		if !next {
+			break
+		}
+	}
+	newPayload, newEnd, newRootEnd = payload, end, rootEnd
+	return
+}
+
+// elementByKey finds the object in the array of payload at start, which has the same value of the field keyField
+// as the object of src at s.
+func elementByKey(payload []byte, start int, src []byte, s location, keyField string) (elem location, found bool, e error) {
+	if s.vtype != valObject {
+		return
+	}
+	var field location
+	if field, found, e = findKey(src, s.start, keyField, ""); e != nil || !found {
+		return
+	}
+	var next, empty bool
+	for pos := start + 1; pos < len(payload); pos++ {
+		if pos, elem.start, elem.end, elem.vtype, next, empty, e = nextValue(payload, pos); e != nil || empty {
+			break
+		}
+		if elem.vtype == valObject {
+			var oldField location
+			if oldField, found, e = findKey(payload, elem.start, keyField, ""); e != nil {
+				return
+			} else if found {
+				if found, e = jsonEqual(payload[oldField.start:oldField.end], src[field.start:field.end]); e != nil || found {
+					return
+				}
+			}
+		}
+		if !next {
+			break
+		}
+	}
+	return elem, false, e
+}
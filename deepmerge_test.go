@@ -0,0 +1,70 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeepMerge(t *testing.T) {
+	const origin = `{"db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"], "users": [{"id": 1, "name": "LBJ"}, {"id": 2}]}`
+	var testSet = []struct {
+		opts    MergeOptions
+		vals    []interface{}
+		expect  string
+		errInfo string
+	}{
+		{MergeOptions{}, []interface{}{"db", map[string]interface{}{"port": 6432, "ssl": true}, "tags", []string{"c"}},
+			`{"db": {"host": "localhost", "port": 6432,"ssl":true}, "tags": ["a", "b","c"], "users": [{"id": 1, "name": "LBJ"}, {"id": 2}]}`, ""},
+		{MergeOptions{Scalars: ScalarKeep}, []interface{}{"db", map[string]interface{}{"port": 6432}, "tags", "c", "new", 1},
+			`{"db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"], "users": [{"id": 1, "name": "LBJ"}, {"id": 2}],"new":1}`, ""},
+		{MergeOptions{Arrays: ArrayReplace, Scalars: ScalarKeep}, []interface{}{"tags", []string{}},
+			`{"db": {"host": "localhost", "port": 5432}, "tags": [], "users": [{"id": 1, "name": "LBJ"}, {"id": 2}]}`, ""},
+		{MergeOptions{Arrays: ArrayMergeByIndex}, []interface{}{"tags", []interface{}{"x"},
+			"users", []interface{}{nil, map[string]interface{}{"name": "AD"}, map[string]interface{}{"id": 3}}},
+			`{"db": {"host": "localhost", "port": 5432}, "tags": ["x", "b"], "users": [null, {"id": 2,"name":"AD"},{"id":3}]}`, ""},
+		// the elements after a merged one which changes its length are still merged at their indexes.
+		{MergeOptions{Arrays: ArrayMergeByIndex}, []interface{}{"users", []interface{}{map[string]interface{}{"name": "LeBron James"},
+			map[string]interface{}{"name": "AD"}}},
+			`{"db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"], "users": [{"id": 1, "name": "LeBron James"}, {"id": 2,"name":"AD"}]}`, ""},
+		{MergeOptions{Arrays: ArrayMergeByIndex}, []interface{}{"tags", []interface{}{}},
+			`{"db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"], "users": [{"id": 1, "name": "LBJ"}, {"id": 2}]}`, ""},
+		{MergeOptions{Arrays: ArrayMergeByKey, KeyField: "id"},
+			[]interface{}{"users", []interface{}{map[string]interface{}{"id": 2, "name": "AD"}, map[string]interface{}{"id": 3}, "x"}},
+			`{"db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"], "users": [{"id": 1, "name": "LBJ"}, {"id": 2,"name":"AD"},{"id":3},"x"]}`, ""},
+		{MergeOptions{}, []interface{}{"db", 1, "tags"}, "", "missing its value"},
+		{MergeOptions{}, []interface{}{1, 1}, "", "must be string"},
+	}
+	for _, set := range testSet {
+		newData, e := DeepMerge([]byte(origin), set.opts, nil, set.vals...)
+		if set.errInfo != "" {
+			if e == nil || strings.Index(e.Error(), set.errInfo) == -1 {
+				t.Fatalf("%v: Expected error %q but got %v", set.vals, set.errInfo, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.vals, e)
+		}
+		if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%v: Expected %s but got %s", set.vals, set.expect, got)
+			t.Fail()
+		}
+	}
+
+	// the nested objects are merged recursively, not wrapped into an array as Merge does with preserve.
+	newData, e := DeepMerge([]byte(`{"a": {"b": {"c": 1, "d": [1]}}}`), MergeOptions{}, Path("a"),
+		map[string]interface{}{"b": map[string]interface{}{"d": []int{2}, "e": "f"}})
+	if e != nil {
+		t.Fatal(e)
+	} else if got := strings.TrimRight(string(newData), " "); got != `{"a": {"b": {"c": 1, "d": [1,2],"e":"f"}}}` {
+		t.Fatalf("Expected the nested objects merged but got %s", got)
+	}
+	if newData, e = DeepMerge([]byte(`{"a": []}`), MergeOptions{Arrays: ArrayMergeByIndex}, nil, "a", []int{1, 2}); e != nil {
+		t.Fatal(e)
+	} else if got := strings.TrimRight(string(newData), " "); got != `{"a": [1,2]}` {
+		t.Fatalf("Expected the elements appended to the empty array but got %s", got)
+	}
+	if _, e = DeepMerge([]byte(`{"a": [1]}`), MergeOptions{}, Path("a"), "b", 1); e == nil ||
+		strings.Index(e.Error(), "not json object") == -1 {
+		t.Fatalf("Expected the not object error but got %v", e)
+	}
+}
@@ -0,0 +1,155 @@
+package hapijson
+
+import (
+	"errors"
+)
+
+// ErrStop could be returned by the callback of ArrayEach and ObjectEach to stop the iteration,
+// it's not returned as an error by them.
+var ErrStop = errors.New("stop iteration")
+
+// ArrayEach calls fn with every element of the last node of the pathNodes which must be an array, in order.
+// value is the slice of the element in data, it's not copied, the quotes are kept if it's a string.
+// The iteration stops once fn returns an error, the error is returned unless it's ErrStop. e.g.
+//
+//	ArrayEach(data, func(index int, value []byte, kind Kind) error {
+//		team, _ := String(value, "team")
+//		...
+//	}, "career")
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func ArrayEach(data []byte, fn func(index int, value []byte, kind Kind) error, pathNodes ...interface{}) (e error) {
+	var start int
+	var vtype valType
+	if start, _, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valArray {
+		return genNotTypeError("not json array", pathNodes)
+	}
+	var vStart, vEnd int
+	var next, empty bool
+	for pos, index := start+1, 0; pos < len(data); pos, index = pos+1, index+1 {
+		if pos, vStart, vEnd, vtype, next, empty, e = nextValue(data, pos); e != nil || empty {
+			return
+		}
+		if e = fn(index, data[vStart:vEnd], kindOf(vtype)); e != nil {
+			if e == ErrStop {
+				e = nil
+			}
+			return
+		}
+		if !next {
+			return
+		}
+	}
+	return ErrInvalidJSONPayload
+}
+
+// ObjectEach calls fn with every key set of the last node of the pathNodes which must be an object, in order.
+// key is the slice of the key in data without the quotes, it's not unescaped, value is the same as ArrayEach's.
+// The duplicated keys are visited as they are, DuplicateKeys is not applied.
+// The iteration stops once fn returns an error, the error is returned unless it's ErrStop.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func ObjectEach(data []byte, fn func(key, value []byte, kind Kind) error, pathNodes ...interface{}) (e error) {
+	var start int
+	var vtype valType
+	if start, _, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valObject {
+		return genNotTypeError("not json object", pathNodes)
+	}
+	var kStart, kEnd, vStart, vEnd int
+	var hasKey, next bool
+	for pos := start + 1; pos < len(data); pos++ {
+		if pos, kStart, kEnd, hasKey, e = nextRawKey(data, pos); e != nil || !hasKey {
+			return
+		} else if pos, vStart, vEnd, vtype, next, _, e = nextValue(data, pos); e != nil {
+			return
+		}
+		if e = fn(data[kStart:kEnd], data[vStart:vEnd], kindOf(vtype)); e != nil {
+			if e == ErrStop {
+				e = nil
+			}
+			return
+		}
+		if !next {
+			return
+		}
+	}
+	return ErrInvalidJSONPayload
+}
+
+// Keys returns the keys of the last node of the pathNodes which must be an object, in the order of the document.
+// The values are not decoded, the duplicated keys are listed as they are, so the keys are corresponding to
+// the values returned by Values.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func Keys(data []byte, pathNodes ...interface{}) (keys []string, e error) {
+	var start int
+	var vtype valType
+	if start, _, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valObject {
+		return nil, genNotTypeError("not json object", pathNodes)
+	}
+	var key string
+	var hasKey, next bool
+	keys = []string{}
+	for pos := start + 1; pos < len(data); pos++ {
+		if pos, key, hasKey, e = nextKey(data, pos, true); e != nil {
+			return nil, e
+		} else if !hasKey {
+			return
+		} else if pos, _, _, _, next, _, e = nextValue(data, pos); e != nil {
+			return nil, e
+		}
+		if keys = append(keys, key); !next {
+			return
+		}
+	}
+	return nil, ErrInvalidJSONPayload
+}
+
+// Values returns the slices of the values of the last node of the pathNodes in the order of the document,
+// which is an object or an array. The values are not copied, the quotes are kept if they are strings.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func Values(data []byte, pathNodes ...interface{}) (vals [][]byte, e error) {
+	var start int
+	var vtype valType
+	if start, _, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valObject && vtype != valArray {
+		return nil, genNotTypeError("neither json object nor json array", pathNodes)
+	}
+	var vStart, vEnd int
+	var hasKey, next, empty bool
+	vals = [][]byte{}
+	for pos := start + 1; pos < len(data); pos++ {
+		if vtype == valObject {
+			if pos, _, _, hasKey, e = nextRawKey(data, pos); e != nil {
+				return nil, e
+			} else if !hasKey {
+				return
+			}
+		}
+		if pos, vStart, vEnd, _, next, empty, e = nextValue(data, pos); e != nil {
+			return nil, e
+		} else if empty {
+			return
+		}
+		if vals = append(vals, data[vStart:vEnd]); !next {
+			return
+		}
+	}
+	return nil, ErrInvalidJSONPayload
+}
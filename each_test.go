@@ -0,0 +1,135 @@
+package hapijson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArrayEach(t *testing.T) {
+	var kinds []Kind
+	var vals []string
+	e := ArrayEach(jsonGetSetData, func(index int, value []byte, kind Kind) error {
+		if index != len(vals) {
+			t.Fatalf("Expected index %d but got %d", len(vals), index)
+		}
+		kinds, vals = append(kinds, kind), append(vals, string(value))
+		return nil
+	}, "reviews", 1, "review")
+	if e != nil {
+		t.Fatal(e)
+	}
+	expectKinds := []Kind{KindObject, KindString, KindString, KindNumber, KindNumber, KindNumber, KindBool, KindBool, KindNull}
+	if !reflect.DeepEqual(kinds, expectKinds) {
+		t.Logf("Expected %v but got %v", expectKinds, kinds)
+		t.Fail()
+	}
+	expectVals := []string{`"the GREATEST ever! \u2764"`, `"the GREATEST ever! ❤"`, "3.141592653", "2", "-1", "false", "true", "null"}
+	if !reflect.DeepEqual(vals[1:], expectVals) {
+		t.Logf("Expected %q but got %q", expectVals, vals[1:])
+		t.Fail()
+	}
+
+	// early termination
+	var count int
+	if e = ArrayEach(jsonGetSetData, func(index int, value []byte, kind Kind) error {
+		if count++; index == 1 {
+			return ErrStop
+		}
+		return nil
+	}, "genre"); e != nil || count != 2 {
+		t.Fatalf("Expected stopping at 2 but got %d, %v", count, e)
+	}
+	errFound := errors.New("found")
+	if e = ArrayEach(jsonGetSetData, func(index int, value []byte, kind Kind) error {
+		return errFound
+	}, "genre"); e != errFound {
+		t.Fatalf("Expected %v but got %v", errFound, e)
+	}
+
+	if e = ArrayEach([]byte(` [ ] `), func(int, []byte, Kind) error {
+		t.Fatal("Unexpected element of empty array")
+		return nil
+	}); e != nil {
+		t.Fatal(e)
+	}
+	if e = ArrayEach(jsonGetSetData, nil, "title"); e == nil || strings.Index(e.Error(), "not json array") == -1 {
+		t.Fatalf("Expected not json array but got %v", e)
+	}
+}
+
+func TestObjectEach(t *testing.T) {
+	var keys, vals []string
+	var kinds []Kind
+	data := []byte(`{"team": "LAL", "no.": 23, "a\"b": {"x": [1]}, "list": [], "empty": {}}`)
+	if e := ObjectEach(data, func(key, value []byte, kind Kind) error {
+		keys, vals, kinds = append(keys, string(key)), append(vals, string(value)), append(kinds, kind)
+		return nil
+	}); e != nil {
+		t.Fatal(e)
+	}
+	expectKeys := []string{"team", "no.", `a\"b`, "list", "empty"}
+	expectVals := []string{`"LAL"`, "23", `{"x": [1]}`, "[]", "{}"}
+	expectKinds := []Kind{KindString, KindNumber, KindObject, KindArray, KindObject}
+	if !reflect.DeepEqual(keys, expectKeys) || !reflect.DeepEqual(vals, expectVals) || !reflect.DeepEqual(kinds, expectKinds) {
+		t.Logf("Expected %q %q %v but got %q %q %v", expectKeys, expectVals, expectKinds, keys, vals, kinds)
+		t.Fail()
+	}
+
+	var count int
+	if e := ObjectEach(data, func(key, value []byte, kind Kind) error {
+		if count++; string(key) == "no." {
+			return ErrStop
+		}
+		return nil
+	}); e != nil || count != 2 {
+		t.Fatalf("Expected stopping at 2 but got %d, %v", count, e)
+	}
+	if e := ObjectEach(data, func([]byte, []byte, Kind) error {
+		t.Fatal("Unexpected key of empty object")
+		return nil
+	}, "empty"); e != nil {
+		t.Fatal(e)
+	}
+	if e := ObjectEach(data, nil, "list"); e == nil || strings.Index(e.Error(), "not json object") == -1 {
+		t.Fatalf("Expected not json object but got %v", e)
+	}
+}
+
+func TestKeysAndValues(t *testing.T) {
+	data := []byte(`{"team": "LAL", "no.": 23, "a\"b": {"x": [1]}, "list": [1, "2", null], "empty": {}, "no.": 6}`)
+	if keys, e := Keys(data); e != nil {
+		t.Fatal(e)
+	} else if expect := []string{"team", "no.", `a"b`, "list", "empty", "no."}; !reflect.DeepEqual(keys, expect) {
+		t.Logf("Expected %q but got %q", expect, keys)
+		t.Fail()
+	}
+	if vals, e := Values(data); e != nil {
+		t.Fatal(e)
+	} else if expect := [][]byte{[]byte(`"LAL"`), []byte("23"), []byte(`{"x": [1]}`), []byte(`[1, "2", null]`),
+		[]byte("{}"), []byte("6")}; !reflect.DeepEqual(vals, expect) {
+		t.Logf("Expected %q but got %q", expect, vals)
+		t.Fail()
+	}
+	if vals, e := Values(data, "list"); e != nil {
+		t.Fatal(e)
+	} else if expect := [][]byte{[]byte("1"), []byte(`"2"`), []byte("null")}; !reflect.DeepEqual(vals, expect) {
+		t.Logf("Expected %q but got %q", expect, vals)
+		t.Fail()
+	}
+	if keys, e := Keys(data, "empty"); e != nil || len(keys) != 0 {
+		t.Fatalf("Expected no keys but got %q, %v", keys, e)
+	} else if vals, e := Values(data, "empty"); e != nil || len(vals) != 0 {
+		t.Fatalf("Expected no values but got %q, %v", vals, e)
+	} else if vals, e := Values([]byte(" [ ] ")); e != nil || vals == nil || len(vals) != 0 {
+		t.Fatalf("Expected no values but got %q, %v", vals, e)
+	}
+	if _, e := Keys(data, "list"); e == nil || strings.Index(e.Error(), "not json object") == -1 {
+		t.Fatalf("Expected not json object but got %v", e)
+	} else if _, e = Values(data, "team"); e == nil || strings.Index(e.Error(), "neither") == -1 {
+		t.Fatalf("Expected neither json object nor json array but got %v", e)
+	} else if _, e = Keys(data, "missing"); e == nil {
+		t.Fatal("Expected an error of key not found")
+	}
+}
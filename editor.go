@@ -0,0 +1,304 @@
+package hapijson
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Editor records many Set, Remove and Incr operations on the same data, and applies them at once, e.g.
+//
+//	newData, e := NewEditor(data).
+//		Set("LAL", "career", -1, "team").
+//		Incr(1, "mvp").
+//		Remove("retired").
+//		Apply()
+//
+// Apply resolves the paths of all the operations in one traversal of data, and builds the new data once in the
+// order of the offsets, rather than scanning data and shifting its tail for each operation.
+// The paths are resolved on the original data, so the operations don't see each other, e.g. removing the element
+// 0 of an array doesn't make the element 1 be the element 0 for the other operations, and they can't edit the
+// same value, or a value inside a removed one, which are conflicts reported by Apply before anything is changed.
+//
+// An Editor is not safe to be used by multiple goroutines.
+type Editor struct {
+	data []byte
+	ops  []editOp
+	// e is the first error of recording operations, it's sticky.
+	e error
+}
+
+type editKind int8
+
+const (
+	editSet editKind = iota
+	editRemove
+	editIncr
+)
+
+// editOp is a recorded operation of Editor.
+type editOp struct {
+	kind      editKind
+	pathNodes []interface{}
+	valJSON   []byte      // for editSet
+	delta     interface{} // for editIncr
+}
+
+// edit is a replacement of payload[start:end] with val.
+type edit struct {
+	start, end int
+	val        []byte
+	// no is the No. of the operation.
+	no int
+}
+
+// NewEditor returns an Editor of data, data is never modified by the Editor.
+//
+// Note: the Editor assuming data is a valid json data, it doesn't do checking inside.
+func NewEditor(data []byte) *Editor {
+	return &Editor{data: data}
+}
+
+// Set records setting val to the last node of the pathNodes, see Set().
+func (ed *Editor) Set(val interface{}, pathNodes ...interface{}) *Editor {
+	valJSON, _, e := toJSON(val)
+	return ed.record(editOp{kind: editSet, pathNodes: pathNodes, valJSON: valJSON}, e)
+}
+
+// Remove records removing the last node of the pathNodes which may be a key or an index, see Remove().
+// The root element can't be removed by an Editor.
+func (ed *Editor) Remove(pathNodes ...interface{}) *Editor {
+	return ed.record(editOp{kind: editRemove, pathNodes: pathNodes}, nil)
+}
+
+// Incr records increasing the number of the last node of the pathNodes by delta, see Incr().
+func (ed *Editor) Incr(delta interface{}, pathNodes ...interface{}) *Editor {
+	return ed.record(editOp{kind: editIncr, pathNodes: pathNodes, delta: delta}, nil)
+}
+
+func (ed *Editor) record(op editOp, e error) *Editor {
+	if ed.e != nil {
+		return ed
+	}
+	if e == nil {
+		op.pathNodes, e = pathNodesOf(op.pathNodes)
+	}
+	if e == nil && op.kind == editRemove && len(op.pathNodes) == 0 {
+		e = fmt.Errorf("can't remove the root element")
+	}
+	if e != nil {
+		ed.e = fmt.Errorf("Error at No.%d operation: %v", len(ed.ops)+1, e)
+	}
+	ed.ops = append(ed.ops, op)
+	return ed
+}
+
+// Apply applies the recorded operations and returns the new data, which is a new buffer.
+// If any of the operations fails, or any two of them conflict, nothing is applied and the error is returned.
+func (ed *Editor) Apply() (newData []byte, e error) {
+	if ed.e != nil {
+		return nil, ed.e
+	}
+	trie := newPathTrie()
+	for id, op := range ed.ops {
+		pathNodes := op.pathNodes
+		if op.kind == editRemove { // the members of the parent are needed for removing.
+			pathNodes = pathNodes[:len(pathNodes)-1]
+		}
+		if e = trie.insert(id, pathNodes); e != nil {
+			return nil, fmt.Errorf("Error at No.%d operation: %v", id+1, e)
+		}
+	}
+	locs, errs := make([]location, len(ed.ops)), make([]error, len(ed.ops))
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(ed.data, 0); e != nil {
+		return
+	}
+	if e = trie.resolve(ed.data, location{start: start, end: end, veryStart: start, vtype: vtype}, 0,
+		func(t *pathTrie, loc location) {
+			for _, id := range t.ids {
+				locs[id] = loc // with LastKeyWins, the later duplicated key overwrites.
+			}
+		},
+		func(t *pathTrie, e error) {
+			t.each(func(id int) { errs[id] = e })
+		}); e != nil {
+		return
+	}
+	for id, e := range errs {
+		if e != nil {
+			return nil, fmt.Errorf("Error at No.%d operation: %v", id+1, e)
+		}
+	}
+
+	var edits, targets []edit
+	// the removals are grouped by their containers, as removing the members of a container affects each other.
+	removals := map[int][]int{}
+	var containers []int
+	for id, op := range ed.ops {
+		loc := locs[id]
+		switch op.kind {
+		case editSet:
+			edits = append(edits, edit{start: loc.start, end: loc.end, val: op.valJSON, no: id + 1})
+		case editIncr:
+			var result []byte
+			if loc.vtype != valNumber && loc.vtype != valFloat {
+				e = genNotTypeError("not json number", op.pathNodes)
+			} else {
+				result, e = calculate(ed.data[loc.start:loc.end], opAdd, op.delta)
+			}
+			if e != nil {
+				return nil, fmt.Errorf("Error at No.%d operation: %v", id+1, e)
+			}
+			edits = append(edits, edit{start: loc.start, end: loc.end, val: result, no: id + 1})
+		case editRemove:
+			if _, ok := removals[loc.start]; !ok {
+				containers = append(containers, loc.start)
+			}
+			removals[loc.start] = append(removals[loc.start], id)
+		}
+	}
+	targets = append(targets, edits...)
+	for _, container := range containers {
+		var removed, removing []edit
+		if removed, removing, e = ed.removeMembers(locs[removals[container][0]], removals[container]); e != nil {
+			return
+		}
+		targets, edits = append(targets, removed...), append(edits, removing...)
+	}
+
+	// the targets of operations must not overlap, they are either the same or one is inside another.
+	sort.SliceStable(targets, func(i, j int) bool { return targets[i].start < targets[j].start })
+	for i := 1; i < len(targets); i++ {
+		if prev := targets[i-1]; targets[i].start < prev.end {
+			no1, no2 := prev.no, targets[i].no
+			if no1 > no2 {
+				no1, no2 = no2, no1
+			}
+			return nil, fmt.Errorf("Error at No.%d operation: conflicts with No.%d operation", no2, no1)
+		}
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	newData = make([]byte, 0, len(ed.data))
+	pos := 0
+	for _, edit := range edits {
+		newData = append(append(newData, ed.data[pos:edit.start]...), edit.val...)
+		pos = edit.end
+	}
+	return append(newData, ed.data[pos:]...), nil
+}
+
+// removeMembers returns the members to be removed from the container at loc by the remove operations of ids as
+// targets, and the edits removing them along with their separators.
+func (ed *Editor) removeMembers(loc location, ids []int) (targets, edits []edit, e error) {
+	var members []edit
+	if members, e = membersOf(ed.data, loc); e != nil {
+		return
+	}
+	removed := make([]bool, len(members))
+	for _, id := range ids {
+		pathNodes := ed.ops[id].pathNodes
+		var matched []int
+		if matched, e = matchMembers(ed.data, loc, members, pathNodes); e != nil {
+			return nil, nil, fmt.Errorf("Error at No.%d operation: %v", id+1, e)
+		}
+		for _, i := range matched {
+			removed[i] = true
+			targets = append(targets, edit{start: members[i].start, end: members[i].end, no: id + 1})
+		}
+	}
+	// the members before the first remaining one are removed with the separators follow them,
+	// the others are removed with the separators before them.
+	first := 0
+	for ; first < len(members) && removed[first]; first++ {
+	}
+	if first == len(members) {
+		edits = append(edits, edit{start: members[0].start, end: members[first-1].end})
+	} else if first > 0 {
+		edits = append(edits, edit{start: members[0].start, end: members[first].start})
+	}
+	for i := first + 1; i < len(members); i++ {
+		if removed[i] {
+			edits = append(edits, edit{start: members[i-1].end, end: members[i].end})
+		}
+	}
+	return
+}
+
+// membersOf returns the key sets or the elements of the container at loc, a member of an object starts at
+// its key, val of a member is the key for an object.
+func membersOf(payload []byte, loc location) (members []edit, e error) {
+	if loc.vtype != valObject && loc.vtype != valArray {
+		return
+	}
+	var next, hasKey, empty bool
+	var key string
+	var member edit
+	for pos := loc.start + 1; pos < len(payload); pos++ {
+		if loc.vtype == valObject {
+			member.start, _ = skipWhites(payload, pos)
+			if pos, key, hasKey, e = nextKey(payload, pos, true); e != nil || !hasKey {
+				return
+			}
+			member.val = []byte(key)
+		}
+		var vStart int
+		if pos, vStart, member.end, _, next, empty, e = nextValue(payload, pos); e != nil || empty {
+			return
+		} else if loc.vtype == valArray {
+			member.start = vStart
+		}
+		if members = append(members, member); !next {
+			return
+		}
+	}
+	e = ErrInvalidJSONPayload
+	return
+}
+
+// matchMembers returns the indexes of the members matched by the last node of the pathNodes, the duplicated
+// keys are matched as Remove does.
+func matchMembers(payload []byte, loc location, members []edit, pathNodes []interface{}) (matched []int, e error) {
+	last := len(pathNodes) - 1
+	what := pathNodes[last]
+	if token, ok := what.(refToken); ok {
+		if what, e = token.node(payload[loc.start]); e != nil {
+			return
+		}
+	}
+	if key, isKey := keyOf(what, valObject); isKey {
+		if loc.vtype != valObject {
+			return nil, fmt.Errorf("the value of %q is not a json object", key)
+		}
+		for i, member := range members {
+			if string(member.val) != key {
+				continue
+			} else if len(matched) > 0 && DuplicateKeys == DuplicateKeyError {
+				return nil, duplicateKeyError(key)
+			}
+			matched = append(matched, i)
+			if DuplicateKeys == FirstKeyWins {
+				break
+			}
+		}
+		if len(matched) == 0 {
+			e = fmt.Errorf(`Error at No.%d in arguments: key %q is not found`, last+1, key)
+		}
+		return
+	}
+	index, ok := what.(int)
+	if !ok {
+		return nil, fmt.Errorf("Unsupported type %T, %v", what, what)
+	} else if loc.vtype != valArray {
+		return nil, fmt.Errorf("the value of %v is not a json array", what)
+	}
+	if index < 0 { // counts from the end
+		index += len(members)
+	}
+	if index < 0 || index >= len(members) {
+		return nil, fmt.Errorf(`Error at No.%d in arguments: index %d out of range, the len is %d`,
+			last+1, what, len(members))
+	}
+	return []int{index}, nil
+}
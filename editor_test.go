@@ -0,0 +1,80 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEditor(t *testing.T) {
+	const origin = `{"name": "LBJ", "mvp": 4, "career": [{"team": "CAVS"}, {"team": "HEAT"}, {"team": "CAVS"}], "retired": false}`
+	var testSet = []struct {
+		edit    func(ed *Editor) *Editor
+		expect  string
+		errInfo string
+	}{
+		{func(ed *Editor) *Editor {
+			return ed.Set("LAL", "career", -1, "team").Incr(1, "mvp").Remove("retired").Set("LeBron", "name")
+		}, `{"name": "LeBron", "mvp": 5, "career": [{"team": "CAVS"}, {"team": "HEAT"}, {"team": "LAL"}]}`, ""},
+		// the paths are resolved on the original data.
+		{func(ed *Editor) *Editor {
+			return ed.Remove("career", 0).Remove("career", 1).Set("LAL", "career", 2, "team")
+		}, `{"name": "LBJ", "mvp": 4, "career": [{"team": "LAL"}], "retired": false}`, ""},
+		{func(ed *Editor) *Editor {
+			return ed.Remove("career", 1).Remove(Pointer("/career/2")).Remove("name")
+		}, `{"mvp": 4, "career": [{"team": "CAVS"}], "retired": false}`, ""},
+		{func(ed *Editor) *Editor {
+			return ed.Remove("career", 0).Remove("career", 1).Remove("career", 2).Remove("retired")
+		}, `{"name": "LBJ", "mvp": 4, "career": []}`, ""},
+		{func(ed *Editor) *Editor { return ed }, origin, ""},
+		// conflicts
+		{func(ed *Editor) *Editor {
+			return ed.Set(1, "mvp").Remove("career", 1).Set("LAL", PathExpr("career[1].team"))
+		}, "", "No.3 operation: conflicts with No.2"},
+		{func(ed *Editor) *Editor { return ed.Incr(1, "mvp").Set(5, "mvp") }, "", "No.2 operation: conflicts with No.1"},
+		{func(ed *Editor) *Editor { return ed.Remove("name").Remove("name") }, "", "conflicts"},
+		// failures
+		{func(ed *Editor) *Editor { return ed.Set(1, "mvp").Set(1, "missing", "a") }, "", "No.2 operation: Error at No.1 in arguments: key"},
+		{func(ed *Editor) *Editor { return ed.Remove("career", 3) }, "", "out of range"},
+		{func(ed *Editor) *Editor { return ed.Remove("name", "first") }, "", "not a json object"},
+		{func(ed *Editor) *Editor { return ed.Incr(1, "name") }, "", "not json number"},
+		{func(ed *Editor) *Editor { return ed.Set(1, "mvp").Remove() }, "", "No.2 operation: can't remove the root element"},
+		{func(ed *Editor) *Editor { return ed.Set(make(chan int), "mvp") }, "", "No.1 operation"},
+	}
+	for i, set := range testSet {
+		data := []byte(origin)
+		newData, e := set.edit(NewEditor(data)).Apply()
+		if string(data) != origin {
+			t.Fatalf("No.%d: data is modified: %s", i, data)
+		}
+		if set.errInfo != "" {
+			if e == nil || strings.Index(e.Error(), set.errInfo) == -1 {
+				t.Fatalf("No.%d: Expected error %q but got %v", i, set.errInfo, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(i, e)
+		}
+		if got := string(newData); got != set.expect {
+			t.Logf("No.%d: Expected %s but got %s", i, set.expect, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestEditorDuplicateKeys(t *testing.T) {
+	defer func(policy DuplicateKeyPolicy) { DuplicateKeys = policy }(DuplicateKeys)
+	const origin = `{"a": 1, "b": 2, "a": 3}`
+	for policy, expect := range map[DuplicateKeyPolicy]string{
+		LastKeyWins:  `{"b": 2}`,
+		FirstKeyWins: `{"b": 2, "a": 3}`,
+	} {
+		DuplicateKeys = policy
+		if newData, e := NewEditor([]byte(origin)).Remove("a").Apply(); e != nil || string(newData) != expect {
+			t.Fatalf("policy %d: Expected %s but got %s, %v", policy, expect, newData, e)
+		}
+	}
+	DuplicateKeys = DuplicateKeyError
+	if _, e := NewEditor([]byte(origin)).Set(0, "a").Apply(); e == nil || strings.Index(e.Error(), "duplicated") == -1 {
+		t.Fatalf("Expected the duplicated error but got %v", e)
+	}
+}
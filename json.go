@@ -1,10 +1,5 @@
 package hapijson
 
-//TODO: bug: handle duplicated keys situation.
-// 		e.g. {"key": 1, "key": 2} the later one should overwrite the former one,
-//		Solution 1: when retrieve the value of a key, we should start searching from the back.
-//		Solution 2: when search a key, we search to the end of the object, and use the last key to return.
-
 //TODO: Clearify error info.
 
 import (
@@ -13,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 )
 
@@ -35,6 +31,25 @@ var (
 	ErrInvalidJSONPayload = errors.New("Invalid JSON payload")
 )
 
+// DuplicateKeyPolicy decides which one is used when an object has duplicated keys, e.g. {"key": 1, "key": 2}.
+type DuplicateKeyPolicy int8
+
+const (
+	// LastKeyWins uses the last one, which is the same as encoding/json does.
+	LastKeyWins DuplicateKeyPolicy = iota
+	// FirstKeyWins uses the first one, it's faster as searching a key stops once the key is found.
+	FirstKeyWins
+	// DuplicateKeyError fails when the key is duplicated.
+	DuplicateKeyError
+)
+
+// DuplicateKeys is the policy of duplicated keys honoured by the getters and setters, it's FirstKeyWins by default.
+// Remove removes all the duplicated keys with LastKeyWins, otherwise the former one would take the place,
+// but only the first one with FirstKeyWins.
+//
+// Set it before using this package, it's not safe to be changed while other goroutines are using it.
+var DuplicateKeys = FirstKeyWins
+
 // Path returns pathNodes in []interface{} for calling Merge/Append handly,
 // pathNodes left empty means get to the root element of json
 func Path(pathNodes ...interface{}) []interface{} {
@@ -54,7 +69,9 @@ func Path(pathNodes ...interface{}) []interface{} {
 // It would not allocate a new memory if the data has suffient place to contain the new value,
 // this means it might do modification right at the data, if caller wants to preserve the original data after
 // set, then make a copy beforehand is needed.
+// SetCopy, RemoveCopy and the other ...Copy functions never modify data, see Clone.
 //
+// Set fails if any of the path nodes is missing, see Upsert() for creating them.
 func Set(data []byte, val interface{}, pathNodes ...interface{}) (newData []byte, e error) {
 	var start, end int
 	if start, end, _, _, e = path(data, 0, pathNodes...); e != nil {
@@ -79,11 +96,14 @@ func Set(data []byte, val interface{}, pathNodes ...interface{}) (newData []byte
 //
 // if preserve is true, it merges the values of new keys with the existing keys' into arrays, otherwise it overwirtes
 // the existing keys. e.g. "key4" in above has not matched with any of the new keys so it stays what it is.
+// To merge the objects recursively, see DeepMerge.
 //
 // vals must be either map[string]interface{}, e.g.
 //	Merge(data, preserve, pathNodes, map[string]interface{}{"key":"val", ...})
 // or pairs of arguments as key sets, e.g.
 //	Merge(data, preserve, pathNodes, "key", "val", "key2", "val2", ....)
+// The new keys are merged in the order of the pairs, or in the sorted order of the keys of the map, so the result
+// is always the same.
 //
 // pathNodes left empty means get to the root element of json
 //
@@ -97,28 +117,11 @@ func Merge(data []byte, preserve bool, pathNodes []interface{}, vals ...interfac
 	} else if vtype != valObject {
 		return nil, genNotTypeError("not json object", pathNodes)
 	}
-	var ok bool
-	var m map[string]interface{}
-	if ln := len(vals); ln == 1 {
-		if m, ok = vals[0].(map[string]interface{}); !ok {
-			e = fmt.Errorf("%v is not a valid argument", vals[0])
-			return
-		}
-	} else if ln%2 != 0 {
-		e = fmt.Errorf("%q missing its value", vals[len(vals)-1])
+	var pairs []interface{}
+	if pairs, e = pairsOf(vals...); e != nil {
 		return
-	} else {
-		m = map[string]interface{}{}
-		for i := 0; i < ln; i += 2 {
-			if key, ok := vals[i].(string); !ok {
-				e = fmt.Errorf("%v must be string as a key name", vals[i])
-				return
-			} else {
-				m[key] = vals[i+1]
-			}
-		}
 	}
-	newData, _, _, e = merge(data, start, end, rootEndOf(data), preserve, m)
+	newData, _, _, e = merge(data, start, end, rootEndOf(data), preserve, pairs)
 	return
 }
 
@@ -147,6 +150,68 @@ func Append(data []byte, pathNodes []interface{}, vals ...interface{}) (newData
 	return
 }
 
+// Insert inserts vals before the element at index of the last node of the pathNodes which must be an array,
+// e.g. json:
+//	{"teams": ["CAVS", "LAL"]}
+// after Insert(data, 1, []interface{}{"HEAT", "CAVS"}, "teams")
+//	{"teams": ["CAVS", "HEAT","CAVS","LAL"]}
+//
+// index could be negative which counts from the end of the array, e.g. -1 inserts vals before the last element,
+// index is the length of the array means appending vals.
+// vals are the same as Append's.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside...
+// See the Note part of Set().
+func Insert(data []byte, index int, vals []interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	if len(vals) == 0 {
+		return data, nil
+	}
+	var start, end int
+	var vtype valType
+	if pathNodes, e = pathNodesOf(pathNodes); e != nil {
+		return
+	} else if start, end, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valArray {
+		return nil, genNotTypeError("not json array", pathNodes)
+	}
+	var j, valJSON []byte
+	for _, val := range vals {
+		if j, _, e = toJSON(val); e != nil {
+			return
+		}
+		valJSON = append(append(valJSON, j...), ',')
+	}
+	newData, e = insertJSON(data, valJSON[:len(valJSON)-1], start, end, index, len(pathNodes)+1)
+	return
+}
+
+// insertJSON inserts the elements in valJSON, which are separated by ',', before the element at index of
+// the array at start, no is the number of the index in the arguments for error info.
+func insertJSON(payload, valJSON []byte, start, end, index, no int) (newPayload []byte, e error) {
+	var aryLength int
+	// valJSON is copied with the closer or the separator, as it may be a slice of other json data.
+	j := make([]byte, len(valJSON)+1)
+	copy(j, valJSON)
+	if aryLength, e = size(payload, valArray, start, end); e != nil {
+		return
+	} else if index == aryLength {
+		j[len(valJSON)] = ']'
+		newPayload, _, _ = appendJSON(payload, j, valArray, start, end, rootEndOf(payload))
+		return
+	}
+	var elemStart int
+	if elemStart, _, _, _, e = path(payload, start, index); e != nil {
+		return nil, fmt.Errorf(`Error at No.%d in arguments: index %d out of range, the len is %d`,
+			no, index, aryLength)
+	}
+	j[len(valJSON)] = ','
+	newPayload, _, _ = updatePayload(payload, j, elemStart, elemStart, rootEndOf(payload))
+	return
+}
+
 // Remove removes a key set or an elements from the last node of
 // the pathNodes which may be a key or an index.
 //
@@ -155,11 +220,35 @@ func Append(data []byte, pathNodes []interface{}, vals ...interface{}) (newData
 // Note: this function assuming data is a valid json data, it doesn't do checking inside...
 // See the Note part of Set().
 func Remove(data []byte, pathNodes ...interface{}) (newData []byte, e error) {
-	if len(pathNodes) == 0 {
+	if pathNodes, e = pathNodesOf(pathNodes); e != nil {
+		return
+	} else if len(pathNodes) == 0 {
 		// removeing the root element
 		return Clear(data, pathNodes...)
 	}
 	var start, end, veryStart int
+	var vtype valType
+	last := len(pathNodes) - 1
+	if _, isIndex := pathNodes[last].(int); !isIndex && DuplicateKeys == LastKeyWins {
+		// remove all the duplicated keys, otherwise the former one would take the place.
+		if start, _, _, vtype, e = path(data, 0, pathNodes[:last]...); e != nil {
+			return
+		}
+		if key, isKey := keyOf(pathNodes[last], vtype); isKey {
+			var locs []location
+			if locs, e = keyLocations(data, start, key); e != nil {
+				return
+			} else if len(locs) == 0 {
+				e = fmt.Errorf(`Error at No.%d in arguments: key %q is not found`, last+1, key)
+				return
+			}
+			newData, rootEnd := data, rootEndOf(data)
+			for i := len(locs) - 1; i >= 0; i-- { // from the back, so the former locations stay the same.
+				newData, _, rootEnd = remove(newData, locs[i].start, locs[i].end, locs[i].veryStart, rootEnd)
+			}
+			return newData, nil
+		}
+	}
 	if start, end, veryStart, _, e = path(data, 0, pathNodes...); e != nil {
 		return
 	}
@@ -167,6 +256,19 @@ func Remove(data []byte, pathNodes ...interface{}) (newData []byte, e error) {
 	return
 }
 
+// keyOf returns the key that a path node means, if it's applied to an object.
+func keyOf(what interface{}, vtype valType) (key string, isKey bool) {
+	switch node := what.(type) {
+	case string:
+		return node, vtype == valObject
+	case compiledKey:
+		return node.key, vtype == valObject
+	case refToken:
+		return string(node), vtype == valObject
+	}
+	return
+}
+
 func remove(payload []byte, start, end, veryStart, rootEnd int) (newPayload []byte, newEnd, newRootEnd int) {
 	// if the key set or element is in the middle of values,
 	// we need to remove its seperator the comma ',' as well,
@@ -228,19 +330,41 @@ func Clear(data []byte, pathNodes ...interface{}) (newData []byte, e error) {
 	return
 }
 
-// Incr increases the number of the last node of pathNodes by delta,
-// delta must be type of int, int64, float32 or float64.
+// Incr increases the number of the last node of pathNodes by delta exactly in decimal, e.g. 0.1 + 0.2 is 0.3,
+// and the number could be of any size. delta could be any of the go integers and floats, *big.Int,
+// or a number in string or json.Number, e.g. "0.1", which is exact while a float may be not.
+//
+// The number is written as it was, e.g. 1.50 + 1 is 2.50, 1.5e3 + 1 is 1.501e3.
+// It fails rather than making a huge number if the exponents of the number and delta are too far apart,
+// e.g. 1 + 1e100000, so do Decr, Mul and Div.
 //
 // pathNodes left empty means get to the root element of json
 //
 // Note: this function assuming data is a valid json encoding, it doesn't do checking inside...
 // See the Note part of Set().
 func Incr(data []byte, delta interface{}, pathNodes ...interface{}) (newData []byte, e error) {
-	return inOrDecrease(data, delta, pathNodes...)
+	return calculateAt(data, opAdd, delta, pathNodes...)
 }
 
-func inOrDecrease(data []byte, delta interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+// Decr decreases the number of the last node of pathNodes by delta, see Incr().
+func Decr(data []byte, delta interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return calculateAt(data, opSub, delta, pathNodes...)
+}
 
+// Mul multiplies the number of the last node of pathNodes by factor, see Incr().
+func Mul(data []byte, factor interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return calculateAt(data, opMul, factor, pathNodes...)
+}
+
+// Div divides the number of the last node of pathNodes by divisor, see Incr().
+// The quotient is exact if it's a finite decimal, otherwise it's rounded to DivScale digits after the decimal
+// point, e.g. 1 / 8 is 0.125, 1 / 3 is 0.3333333333333333.
+func Div(data []byte, divisor interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	return calculateAt(data, opDiv, divisor, pathNodes...)
+}
+
+// calculateAt calculates on the number of the last node of pathNodes, see calculate().
+func calculateAt(data []byte, op byte, operand interface{}, pathNodes ...interface{}) (newData []byte, e error) {
 	var start, end int
 	var vtype valType
 	if start, end, _, vtype, e = path(data, 0, pathNodes...); e != nil {
@@ -249,77 +373,16 @@ func inOrDecrease(data []byte, delta interface{}, pathNodes ...interface{}) (new
 	if vtype != valNumber && vtype != valFloat {
 		return nil, genNotTypeError("not json number", pathNodes)
 	}
-	iNum, e := fromJSON(data, start, end, vtype)
-	if e != nil {
-		return
-	}
 	var result []byte
-	switch n := iNum.(type) {
-	case int:
-		switch d := delta.(type) {
-		case int:
-			result = []byte(strconv.Itoa(n + d))
-		case int64:
-			result = []byte(strconv.FormatInt(int64(n)+d, 10))
-		case float32:
-			result = []byte(strconv.FormatFloat(float64(n)+float64(d), 'f', -1, 32))
-		case float64:
-			result = []byte(strconv.FormatFloat(float64(n)+d, 'f', -1, 64))
-		default:
-			e = fmt.Errorf("increase type of %T is unsupported by now", n)
-			return
-		}
-	case int64:
-		switch d := delta.(type) {
-		case int:
-			result = []byte(strconv.FormatInt(n+int64(d), 10))
-		case int64:
-			result = []byte(strconv.FormatInt(n+d, 10))
-		case float32:
-			result = []byte(strconv.FormatFloat(float64(float32(n)+d), 'f', -1, 32))
-		case float64:
-			result = []byte(strconv.FormatFloat(float64(n)+d, 'f', -1, 64))
-		default:
-			e = fmt.Errorf("increase type of %T is unsupported by now", n)
-			return
-		}
-	case float32:
-		switch d := delta.(type) {
-		case int:
-			result = []byte(strconv.FormatFloat(float64(n+float32(d)), 'f', -1, 32))
-		case int64:
-			result = []byte(strconv.FormatFloat(float64(n)+float64(d), 'f', -1, 32))
-		case float32:
-			result = []byte(strconv.FormatFloat(float64(n+d), 'f', -1, 32))
-		case float64:
-			result = []byte(strconv.FormatFloat(float64(n)+d, 'f', -1, 64))
-		default:
-			e = fmt.Errorf("increase type of %T is unsupported by now", n)
-			return
-		}
-	case float64:
-		switch d := delta.(type) {
-		case int:
-			result = []byte(strconv.FormatFloat(n+float64(d), 'f', -1, 64))
-		case int64:
-			result = []byte(strconv.FormatFloat(n+float64(d), 'f', -1, 64))
-		case float32:
-			result = []byte(strconv.FormatFloat(n+float64(d), 'f', -1, 32))
-		case float64:
-			result = []byte(strconv.FormatFloat(n+d, 'f', -1, 64))
-		default:
-			e = fmt.Errorf("increase type of %T is unsupported by now", n)
-			return
-		}
-	default:
-		e = fmt.Errorf("increase type of %T is unsupported by now", n)
+	if result, e = calculate(data[start:end], op, operand); e != nil {
 		return
 	}
 	newData, _, _ = updatePayload(data, result, start, end, rootEndOf(data))
 	return
 }
 
-// Get gets val from the last node of the pathNodes, it may be a key or an index.
+// Get gets val from the last node of the pathNodes, it may be a key or an index,
+// a negative index counts from the end of an array, e.g. -1 means the last element.
 // PS: use specific functions like String,Int,Bool or StringArray , etc.., can
 // get a specific type of value instead of interface{}.
 //
@@ -751,12 +814,26 @@ func Size(data []byte, pathNodes ...interface{}) (s int, e error) {
 func size(payload []byte, vtype valType, start, end int) (size int, e error) {
 	pos := start + 1 // skip the { or [
 	if vtype == valObject {
+		// the duplicated keys are counted once, the same as the size of the map decoded from the object,
+		// the keys are unescaped only if they have escaped characters.
+		keys := map[string]bool{}
 		for ; pos < end; pos++ {
+			var key string
+			var kStart, kEnd int
 			var hasKey bool
-			if pos, _, hasKey, e = nextKey(payload, pos, false); !hasKey || e != nil {
+			if pos, kStart, kEnd, hasKey, e = nextRawKey(payload, pos); !hasKey || e != nil {
 				return
+			} else if bytes.IndexByte(payload[kStart:kEnd], '\\') == -1 {
+				key = string(payload[kStart:kEnd])
+			} else if key, _, e = unescapeString(payload, kStart); e != nil {
+				return 0, e
+			}
+			if !keys[key] {
+				keys[key] = true
+				size++
+			} else if DuplicateKeys == DuplicateKeyError {
+				return 0, duplicateKeyError(key)
 			}
-			size++
 			if newPos, _, _, _, next, _, e := nextValue(payload, pos); e != nil {
 				return 0, e
 			} else if next {
@@ -786,55 +863,63 @@ func size(payload []byte, vtype valType, start, end int) (size int, e error) {
 
 // veryStart is the start of a key or an element, it points to the '{', '[' or ',' prior to the key or element.
 func path(payload []byte, startPos int, pathNodes ...interface{}) (start, end, veryStart int, vtype valType, e error) {
-	if ln := len(pathNodes); ln == 0 {
+	if pathNodes, e = pathNodesOf(pathNodes); e != nil {
+		return
+	} else if len(pathNodes) == 0 {
 		var ok bool
 		if start, end, vtype, ok = root(payload); !ok { // to the root of payload
 			e = ErrInvalidJSONPayload
 		}
 		return
-	} else if ln == 1 {
-		if pns, ok := pathNodes[0].([]interface{}); ok {
-			pathNodes = pns
-		}
 	}
 
 readArgs:
 	for argI, what := range pathNodes {
 		startPos, _ = skipWhites(payload, startPos)
 		veryStart = startPos
+		if token, ok := what.(refToken); ok { // a reference token of JSON Pointer, is a key or an index
+			if what, e = token.node(payload[startPos]); e != nil {
+				return
+			}
+		}
+		var escaped string
+		if ck, ok := what.(compiledKey); ok {
+			what, escaped = ck.key, ck.escaped
+		}
 		if key, ok := what.(string); ok { // key
 			if payload[startPos] != '{' {
 				e = fmt.Errorf("the value of %q is not a json object", key)
 				return
 			}
-			var tempKey string
-			var next, hasKey bool
-			for startPos++; startPos < len(payload); startPos++ { // iterate keys for the key.
-				if startPos, tempKey, hasKey, e = nextKey(payload, startPos, true); e != nil {
-					return
-				} else if !hasKey {
-					e = fmt.Errorf(`Error at No.%d in arguments: key %q is not found`, argI+1, key)
-					return
-				} else if startPos, start, end, vtype, next, _, e = nextValue(payload, startPos); e != nil {
-					return
-				} else if key == tempKey { // ok, done.
-					startPos = start // the pos now is that where the value of this key start at.
-					continue readArgs
-				} else if !next {
-					e = fmt.Errorf(`Error at No.%d in arguments: key %q is not found`, argI+1, key)
-					return
-				} else {
-					veryStart = startPos
-				}
+			var loc location
+			var found bool
+			if loc, found, e = findKey(payload, startPos, key, escaped); e != nil {
+				return
+			} else if !found {
+				e = fmt.Errorf(`Error at No.%d in arguments: key %q is not found`, argI+1, key)
+				return
 			}
-			e = ErrInvalidJSONPayload
-			return
+			start, end, veryStart, vtype = loc.start, loc.end, loc.veryStart, loc.vtype
+			startPos = start // the pos now is that where the value of this key start at.
+			continue readArgs
 
 		} else if index, ok := what.(int); ok { // index
 			if payload[startPos] != '[' {
 				e = fmt.Errorf("the value of %v is not a json array", what)
 				return
 			}
+			if index < 0 { // counts from the end
+				var aryLength int
+				if start, end, veryStart, vtype, aryLength, e = nthFromEnd(payload, startPos, index); e != nil {
+					return
+				} else if aryLength+index < 0 { // rather than -index, which overflows with math.MinInt64.
+					e = fmt.Errorf(`Error at No.%d in arguments: index %d out of range, the len is %d`,
+						argI+1, index, aryLength)
+					return
+				}
+				startPos = start
+				continue readArgs
+			}
 			var aryLength int
 			var empty, next bool
 			for startPos++; startPos < len(payload); startPos++ {
@@ -870,6 +955,101 @@ readArgs:
 	return
 }
 
+// findKey searches key in the object starts at pos, which one of the duplicated keys is found depends on
+// DuplicateKeys. escaped see nextKeyMatch.
+func findKey(payload []byte, pos int, key, escaped string) (loc location, found bool, e error) {
+	var next, hasKey, matched bool
+	var start, end int
+	var vtype valType
+	veryStart := pos
+	for pos++; pos < len(payload); pos++ { // iterate keys for the key.
+		if pos, matched, hasKey, e = nextKeyMatch(payload, pos, key, escaped); e != nil || !hasKey {
+			return
+		} else if pos, start, end, vtype, next, _, e = nextValue(payload, pos); e != nil {
+			return
+		}
+		if matched {
+			if found && DuplicateKeys == DuplicateKeyError {
+				e = duplicateKeyError(key)
+				return
+			}
+			loc, found = location{start: start, end: end, veryStart: veryStart, vtype: vtype}, true
+			if DuplicateKeys == FirstKeyWins { // ok, done.
+				return
+			}
+		}
+		if !next {
+			return
+		}
+		veryStart = pos
+	}
+	e = ErrInvalidJSONPayload
+	return
+}
+
+// keyLocations returns all the locations of key in the object starts at pos, including the duplicated ones.
+func keyLocations(payload []byte, pos int, key string) (locs []location, e error) {
+	var next, hasKey, matched bool
+	var loc location
+	loc.veryStart = pos
+	for pos++; pos < len(payload); pos++ {
+		if pos, matched, hasKey, e = nextKeyMatch(payload, pos, key, ""); e != nil || !hasKey {
+			return
+		} else if pos, loc.start, loc.end, loc.vtype, next, _, e = nextValue(payload, pos); e != nil {
+			return
+		}
+		if matched {
+			locs = append(locs, loc)
+		}
+		if !next {
+			return
+		}
+		loc.veryStart = pos
+	}
+	e = ErrInvalidJSONPayload
+	return
+}
+
+func duplicateKeyError(key string) error {
+	return fmt.Errorf("key %q is duplicated", key)
+}
+
+// nthFromEnd returns the element of a negative index in the array starts at startPos, e.g. -1 is the last element,
+// it walks through the array only once by keeping the last -index elements, the ring of them grows with the
+// elements seen, so it's never larger than the array however small index is.
+// If aryLength is less than -index, the element doesn't exist.
+func nthFromEnd(payload []byte, startPos, index int) (start, end, veryStart int, vtype valType, aryLength int, e error) {
+	type element struct {
+		start, end, veryStart int
+		vtype                 valType
+	}
+	var buf [8]element
+	ring := buf[:0]
+	var next, empty bool
+	veryStart = startPos
+	for startPos++; startPos < len(payload); startPos++ {
+		if startPos, start, end, vtype, next, empty, e = nextValue(payload, startPos); e != nil || empty {
+			return
+		}
+		if len(ring)+index < 0 { // not full yet
+			ring = append(ring, element{start, end, veryStart, vtype})
+		} else {
+			ring[aryLength%len(ring)] = element{start, end, veryStart, vtype}
+		}
+		aryLength++
+		if !next {
+			if aryLength+index >= 0 {
+				ele := ring[aryLength%len(ring)] // the oldest one is exactly the element of index.
+				start, end, veryStart, vtype = ele.start, ele.end, ele.veryStart, ele.vtype
+			}
+			return
+		}
+		veryStart = startPos
+	}
+	e = ErrInvalidJSONPayload
+	return
+}
+
 // to the root element of json payload.
 func root(payload []byte) (rootStart, rootEnd int, vtype valType, ok bool) {
 	if rootStart, ok = skipWhites(payload, 0); !ok {
@@ -931,6 +1111,55 @@ func nextKey(payload []byte, curIndex int, encodeToUTF8 bool) (newPos int, key s
 	return
 }
 
+// nextKeyMatch reads the next key like nextKey does, and reports whether the key is matched.
+// If escaped is not empty, which is the key escaped by escapeKey, the key is compared in bytes
+// and is unescaped only if it contains escaped characters.
+func nextKeyMatch(payload []byte, curIndex int, key, escaped string) (newPos int, matched, hasKey bool, e error) {
+	if escaped == "" {
+		var tempKey string
+		newPos, tempKey, hasKey, e = nextKey(payload, curIndex, true)
+		return newPos, hasKey && key == tempKey, hasKey, e
+	}
+	var keyStart, keyEnd int
+	if newPos, keyStart, keyEnd, hasKey, e = nextRawKey(payload, curIndex); !hasKey || e != nil {
+		return
+	}
+	raw := payload[keyStart:keyEnd]
+	if matched = string(raw) == escaped; !matched && bytes.IndexByte(raw, '\\') != -1 {
+		var tempKey string
+		if tempKey, _, e = unescapeString(payload, keyStart); e != nil {
+			return
+		}
+		matched = tempKey == key
+	}
+	return
+}
+
+// nextRawKey reads the next key like nextKey does, but the key is not unescaped,
+// payload[keyStart:keyEnd] is the key without quotes.
+func nextRawKey(payload []byte, curIndex int) (newPos, keyStart, keyEnd int, hasKey bool, e error) {
+	for pos := curIndex; pos < len(payload); pos++ {
+		switch payload[pos] {
+		case '"':
+			for keyStart, pos = pos+1, pos+1; pos < len(payload); pos++ {
+				if b := payload[pos]; b == '\\' {
+					pos++
+				} else if b == '"' {
+					break
+				}
+			}
+			keyEnd = pos
+		case ':':
+			newPos, hasKey = pos+1, true
+			return
+		case '}': // end of an object, it's an empty {}
+			return
+		}
+	}
+	e = ErrInvalidJSONPayload
+	return
+}
+
 // valStart and valEnd is meant to be used to get a slice out of payload, newPos always
 // points to a position that the next process should begin at.
 //
@@ -1144,10 +1373,14 @@ func toMap(payload []byte, start, end int) (m map[string]interface{}, e error) {
 		if pos, vStart, vEnd, vType, next, _, e = nextValue(payload, pos); e != nil {
 			return
 		}
-		if val, e = fromJSON(payload, vStart, vEnd, vType); e != nil {
-			return
+		if _, ok := m[key]; !ok || DuplicateKeys == LastKeyWins {
+			if val, e = fromJSON(payload, vStart, vEnd, vType); e != nil {
+				return
+			}
+			m[key] = val
+		} else if DuplicateKeys == DuplicateKeyError {
+			return nil, duplicateKeyError(key)
 		}
-		m[key] = val
 		if next {
 			pos++
 		} else {
@@ -1258,9 +1491,18 @@ func toJSON(val interface{}) (j []byte, jtype valType, e error) {
 		j[len(j)-1] = ']'
 
 	case map[string]interface{}:
+		if len(v) == 0 {
+			j, jtype = []byte{'{', '}'}, valObject
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // in a stable order
 		var objJSON string
-		for key, val := range v {
-			if j, _, e = toJSON(val); e != nil {
+		for _, key := range keys {
+			if j, _, e = toJSON(v[key]); e != nil {
 				return
 			}
 			objJSON += fmt.Sprintf(`"%s":%s,`, escape(key), string(j))
@@ -1439,111 +1681,102 @@ func appendJSON(payload, json []byte, payloadType valType, start, end, rootEnd i
 	return
 }
 
-func merge(payload []byte, start, end, rootEnd int, preserve bool, val map[string]interface{}) (newPayload []byte,
+// pairsOf returns the pairs of keys and values in vals which are either a map[string]interface{} or pairs of
+// arguments as key sets, the keys of the map are sorted.
+func pairsOf(vals ...interface{}) (pairs []interface{}, e error) {
+	if ln := len(vals); ln == 1 {
+		m, ok := vals[0].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v is not a valid argument", vals[0])
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			pairs = append(pairs, key, m[key])
+		}
+		return
+	} else if ln%2 != 0 {
+		return nil, fmt.Errorf("%q missing its value", vals[len(vals)-1])
+	}
+	for i := 0; i < len(vals); i += 2 {
+		if _, ok := vals[i].(string); !ok {
+			return nil, fmt.Errorf("%v must be string as a key name", vals[i])
+		}
+	}
+	return vals, nil
+}
+
+func merge(payload []byte, start, end, rootEnd int, preserve bool, pairs []interface{}) (newPayload []byte,
 	newEnd, newRootEnd int, e error) {
 
-	var oldKey string
-	var next, empty bool
-	var vStart, vEnd int
-	var newValType, oldValType valType
+	var newValType valType
 	var newValJSON []byte
-readNewKey:
-	for newKey, newVal := range val {
-		if newValJSON, newValType, e = toJSON(newVal); e != nil {
+	for i := 0; i < len(pairs); i += 2 {
+		if newValJSON, newValType, e = toJSON(pairs[i+1]); e != nil {
 			return
 		}
-	readOldKey:
-		for i := start + 1; i < end; i++ {
-			if i, oldKey, next, e = nextKey(payload, i, true); e != nil {
-				return
-			} else if next {
-				if i, vStart, vEnd, oldValType, next, empty, e = nextValue(payload, i); e != nil {
-					return
-				} else if empty {
-					// no value is found, set vStar and vEnd with key end
-					vStart, vEnd, oldValType = i, i, valNull
-				}
-			}
-			if oldKey != newKey {
-				if !next {
-					// this new key doesn't match with any old keys, so add it to the object end directly
-					newValJSON = []byte(fmt.Sprintf(`"%s":%s}`, escape(newKey), string(newValJSON)))
-					payload, end, rootEnd = appendJSON(payload, newValJSON, valObject, start, end, rootEnd)
-					continue readNewKey
-				}
-				continue readOldKey
-			}
-			if !preserve { // don't preseve the old value, overwrite it directly.
-				payload, newEnd, rootEnd =
-					updatePayload(payload, newValJSON, vStart, vEnd, rootEnd)
-				end = end + (newEnd - vEnd)
-				continue readNewKey
-			} else {
-				// merging
-				// merge the oVal and newVal .
-				switch oldValType {
-				case valArray:
-					if newValType == valArray { // append newval into array
-						newValJSON = newValJSON[1:] // skip the [
-					} else {
-						newValJSON = append(newValJSON, ']')
-					}
-					payload, newEnd, rootEnd =
-						appendJSON(payload, newValJSON, oldValType, vStart, vEnd, rootEnd)
+		if payload, end, rootEnd, e = mergeJSON(payload, start, end, rootEnd, preserve, pairs[i].(string),
+			newValJSON, newValType); e != nil {
+			return
+		}
+	}
+	newPayload, newEnd, newRootEnd = payload, end, rootEnd
+	return
+}
 
-					end = end + (newEnd - vEnd)
-					continue readNewKey
-				default:
-					goto merge_to_array
-					/* 				case valString, valTrue, valFalse, valNumber, valFloat, valNull: // merge to array
-					   					goto merge_to_array
-					   				case valObject:
-					   					if newValType == valObject {
-					   						if payload, end, rootEnd, e =
-					   							merge(payload, vStart, vEnd, rootEnd, preserve, newVal.(map[string]interface{})); e != nil {
-					   							return
-					   						}
-					   						continue readNewKey
-					   					} else {
-					   						goto merge_to_array
-					   					} */
-				}
-				e = ErrInvalidJSONPayload
-				return
-				// must jump over
-			merge_to_array:
-				oldValJSON := payload[vStart:vEnd]
-				if newValType == valArray {
-					if len(newValJSON) == 2 { // empty array
-						newValJSON = append(newValJSON[1:], oldValJSON...)
-					} else {
-						newValJSON[1] = ','
-						newValJSON = append(newValJSON, oldValJSON...)
-					}
-					newValJSON = append(newValJSON, ']')
-				} else {
-					j := make([]byte, 3+len(oldValJSON)+len(newValJSON))
-					j[0] = '['
-					copy(j[1:], oldValJSON)
-					i := 1 + len(oldValJSON)
-					j[i], i = ',', i+1
-					copy(j[i:], newValJSON)
-					i += len(newValJSON)
-					j[i] = ']'
-					newValJSON = j
-				}
-				payload, newEnd, rootEnd =
-					updatePayload(payload, newValJSON, vStart, vEnd, rootEnd)
-				end = end + (newEnd - vEnd)
-				continue readNewKey
+// mergeJSON merges the key newKey with the value newValJSON into the object at start, see Merge.
+func mergeJSON(payload []byte, start, end, rootEnd int, preserve bool, newKey string, newValJSON []byte,
+	newValType valType) (newPayload []byte, newEnd, newRootEnd int, e error) {
 
-			}
+	var found bool
+	var old location
+	if old, found, e = findKey(payload, start, newKey, ""); e != nil {
+		return
+	}
+	if !found {
+		// this new key doesn't match with any old keys, so add it to the object end directly
+		newValJSON = []byte(fmt.Sprintf(`"%s":%s}`, escape(newKey), string(newValJSON)))
+		newPayload, newEnd, newRootEnd = appendJSON(payload, newValJSON, valObject, start, end, rootEnd)
+		return
+	}
+	if !preserve { // don't preseve the old value, overwrite it directly.
+		payload, newEnd, rootEnd = updatePayload(payload, newValJSON, old.start, old.end, rootEnd)
+	} else if old.vtype == valArray { // append newval into array
+		if newValType == valArray {
+			newValJSON = trimWhites(newValJSON[1:]) // skip the [
+		} else {
+			newValJSON = append(newValJSON, ']')
 		}
+		if len(newValJSON) == 1 && newValType == valArray { // nothing to append from an empty array.
+			newEnd = old.end
+		} else {
+			payload, newEnd, rootEnd = appendJSON(payload, newValJSON, old.vtype, old.start, old.end, rootEnd)
+		}
+	} else { // merge the old value and newVal into an array.
+		newValJSON = mergeToArray(payload[old.start:old.end], newValJSON, newValType)
+		payload, newEnd, rootEnd = updatePayload(payload, newValJSON, old.start, old.end, rootEnd)
 	}
-	newPayload, newEnd, newRootEnd = payload, end, rootEnd
+	newPayload, newEnd, newRootEnd = payload, end+(newEnd-old.end), rootEnd
 	return
 }
 
+// mergeToArray makes an array of the old value followed by the new value, the elements of
+// the new value are taken if it's an array.
+func mergeToArray(oldValJSON, newValJSON []byte, newValType valType) []byte {
+	if newValType == valArray {
+		newValJSON = trimWhites(newValJSON[1 : len(newValJSON)-1]) // the elements
+	}
+	j := make([]byte, 0, 3+len(oldValJSON)+len(newValJSON))
+	j = append(append(j, '['), oldValJSON...)
+	if len(newValJSON) > 0 {
+		j = append(append(j, ','), newValJSON...)
+	}
+	return append(j, ']')
+}
+
 // for object { and array [
 func readToClose(payload []byte, opener, closer byte, currentIndex int) (newPos int, e error) {
 	var level int
@@ -1838,36 +2071,7 @@ validating:
 
 		case '"':
 			var ok bool
-		readQuotes:
-			for newPos++; newPos < len(payload); newPos++ {
-				switch payload[newPos] {
-				case '\\': // escape
-					newPos++
-					var remain int8
-					if b := payload[newPos]; b == 'u' { //
-						// validate unicode, \u must followed by a four-hex-digit string.
-						remain = 4
-					} else if b == 'x' { // escaped hex unit \x+two-hex-digit
-						remain = 2
-					} else {
-						continue readQuotes
-					}
-					// validate hex digits
-					for newPos++; newPos < len(payload); newPos++ {
-						// if b not in the range of 0-9, a-f or A-F then fail.
-						if b := payload[newPos]; (b < '0' || b > '9') && (b < 'a' || b > 'f') && (b < 'A' || b > 'F') {
-							goto fail // invalid unicode code point or escaped hex unit.
-						}
-						if remain--; remain == 0 {
-							continue readQuotes
-						}
-					}
-				case '"':
-					ok = true
-					break readQuotes
-				}
-			}
-			if !ok {
+			if newPos, ok = scanString(payload, newPos); !ok {
 				goto fail
 			}
 			if status == statusKey || status == statusObj {
@@ -1902,53 +2106,12 @@ validating:
 			if status != statusVal && status != statusAry {
 				goto fail
 			} else {
-				if b := payload[newPos]; (b >= '0' && b <= '9') || b == '-' || b == '.' {
-					// number
-					pos = newPos
-				numbering:
-					for newPos++; newPos < len(payload); newPos++ {
-						switch payload[newPos] {
-						case ',', '}', ']':
-							if !validateNumber(payload, pos, newPos) {
-								goto fail
-							}
-							status, newPos = statusValEnd, newPos-1
-							continue validating
-						case ' ', '\t', '\n', '\r' /* , '\f', '\b' */ : // white characters.
-							break numbering
-						}
-					}
-					if !validateNumber(payload, pos, newPos) {
-						goto fail
-					}
-				} else if b == 'f' { // false
-					if newPos += 4; newPos >= len(payload) {
-						goto fail
-					} else if payload[newPos-3] != 'a' || payload[newPos-2] != 'l' ||
-						payload[newPos-1] != 's' || payload[newPos] != 'e' {
-						goto fail
-					}
-
-				} else if b == 't' { // true
-					if newPos += 3; newPos >= len(payload) {
-						goto fail
-					} else if payload[newPos-2] != 'r' || payload[newPos-1] != 'u' ||
-						payload[newPos] != 'e' {
-						goto fail
-					}
-
-				} else if b == 'n' { // null
-					if newPos += 3; newPos >= len(payload) {
-						goto fail
-					} else if payload[newPos-2] != 'u' || payload[newPos-1] != 'l' ||
-						payload[newPos] != 'l' {
-						goto fail
-					}
-				} else {
+				var ok bool
+				if newPos, _, ok = scanScalar(payload, newPos); !ok {
 					goto fail
 				}
 				// loop to the value delimiters, the ',' , '}' and ']'
-				for newPos++; newPos < len(payload); newPos++ {
+				for ; newPos < len(payload); newPos++ {
 					switch payload[newPos] {
 					case ',', '}', ']':
 						// leave the ',' and ] and } to the main loop handle.
@@ -1972,6 +2135,74 @@ fail:
 	return
 }
 
+// scanString scans a string starts at the quote at pos, end is the position of the closing quote,
+// or the position where it fails.
+func scanString(payload []byte, pos int) (end int, ok bool) {
+readQuotes:
+	for end = pos + 1; end < len(payload); end++ {
+		switch payload[end] {
+		case '\\': // escape
+			if end++; end == len(payload) {
+				return
+			}
+			var remain int8
+			if b := payload[end]; b == 'u' { //
+				// validate unicode, \u must followed by a four-hex-digit string.
+				remain = 4
+			} else if b == 'x' { // escaped hex unit \x+two-hex-digit
+				remain = 2
+			} else {
+				continue readQuotes
+			}
+			// validate hex digits
+			for end++; end < len(payload); end++ {
+				// if b not in the range of 0-9, a-f or A-F then fail.
+				if b := payload[end]; (b < '0' || b > '9') && (b < 'a' || b > 'f') && (b < 'A' || b > 'F') {
+					return // invalid unicode code point or escaped hex unit.
+				}
+				if remain--; remain == 0 {
+					continue readQuotes
+				}
+			}
+		case '"':
+			return end, true
+		}
+	}
+	return
+}
+
+// scanScalar scans a number, true, false or null starts at pos, end is the position right after the value,
+// or the position where it fails.
+func scanScalar(payload []byte, pos int) (end int, vtype valType, ok bool) {
+	var literal string
+	switch b := payload[pos]; {
+	case (b >= '0' && b <= '9') || b == '-' || b == '.':
+		// number, it ends at a white character or a value delimiter.
+	numbering:
+		for end = pos + 1; end < len(payload); end++ {
+			switch payload[end] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r' /* , '\f', '\b' */ :
+				break numbering
+			}
+		}
+		return end, valNumber, validateNumber(payload, pos, end)
+	case b == 'f':
+		literal, vtype = "false", valFalse
+	case b == 't':
+		literal, vtype = "true", valTrue
+	case b == 'n':
+		literal, vtype = "null", valNull
+	default:
+		return pos, valUnknown, false
+	}
+	for end = pos + 1; end < len(payload) && end-pos < len(literal); end++ {
+		if payload[end] != literal[end-pos] {
+			return
+		}
+	}
+	return end, vtype, end-pos == len(literal)
+}
+
 func getErrorInfo(payload []byte, i int) (e error) {
 	if i < len(payload) {
 		i++
@@ -2049,6 +2280,7 @@ func validateNumber(payload []byte, pos, end int) bool {
 /********* End of  validation functions*/
 
 // Minify minifys the json document.
+// It compacts json in place, see MinifyCopy for keeping json unmodified.
 func Minify(json []byte) (minified []byte) {
 	if len(json) == 0 {
 		return json
@@ -2067,7 +2299,7 @@ func minify(payload []byte, keepSpace bool) (end int) {
 	// minified = make([]byte, len(json))
 	for i = 0; i < ln; i++ {
 		b := payload[i]
-		if keepSpace && b == ',' || b == ':' { // keep the space follows ',' or ':'
+		if keepSpace && (b == ',' || b == ':') { // keep the space follows ',' or ':'
 			i++
 			if i < ln && payload[i] == ' ' {
 				if start == -1 {
@@ -39,6 +39,7 @@ func TestCompletely(t *testing.T) {
 	t.Run("Setters", TestSetters)
 	t.Run("Validator", TestValidate)
 	t.Run("Prettifer/Minify", TestPrettifyAndMinify)
+	t.Run("Minify", TestMinify)
 }
 
 func TestValidate(t *testing.T) {
@@ -80,6 +81,25 @@ func TestPrettifyAndMinify(t *testing.T) {
 	Prettify(j, 2)
 }
 
+func TestMinify(t *testing.T) {
+	var testSet = []TestSet{
+		// the space follows ':' was kept, as `{"a": 1,"b": [1,2]}`, only Prettify keeps the spaces.
+		{before: `{"a": 1, "b": [1, 2]}`, expect: `{"a":1,"b":[1,2]}`},
+		{before: "{\n\t\"a\" :\t\"x: y, z\" ,\n\t\"b\": {}\n}", expect: `{"a":"x: y, z","b":{}}`},
+		{before: ` [ 1 , "\" : " ] `, expect: `[1,"\" : "]`},
+	}
+	for _, set := range testSet {
+		if got := string(Minify([]byte(set.before.(string)))); got != set.expect {
+			t.Logf("Expected %s but got %s", set.expect, got)
+			t.Fail()
+		}
+	}
+	// Prettify keeps a space follows ':' of the arrays and objects in one line.
+	if got := string(Prettify([]byte(`{"a":{"b": 1}}`), 2)); !strings.Contains(got, `"a": {`) {
+		t.Fatalf("Unexpected prettified %s", got)
+	}
+}
+
 func TestGetters(t *testing.T) {
 	t.Run("get Root", TestRoot)
 	t.Run("get String", TestString)
@@ -117,6 +137,7 @@ func TestSetters(t *testing.T) {
 	t.Run("set InterfaceArray", TestSetInterfaceArray)
 
 	t.Run("Merge", TestMerge)
+	t.Run("Merge in order", TestMergeInOrder)
 	t.Run("Append", TestAppend)
 	t.Run("Remove", TestRemove)
 	t.Run("Clear", TestClear)
@@ -1069,6 +1090,30 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeInOrder(t *testing.T) {
+	const origin = `{"a": 1}`
+	var testSet = []struct {
+		vals   []interface{}
+		expect string
+	}{
+		{[]interface{}{"z", 1, "b", 2, "m", 3}, `{"a": 1,"z":1,"b":2,"m":3}`},
+		{[]interface{}{map[string]interface{}{"z": 1, "b": 2, "m": 3}}, `{"a": 1,"b":2,"m":3,"z":1}`},
+		{[]interface{}{"o", map[string]interface{}{"z": []interface{}{}, "b": map[string]interface{}{}, "m": nil}},
+			`{"a": 1,"o":{"b":{},"m":null,"z":[]}}`},
+	}
+	for _, set := range testSet {
+		// the same call makes the same bytes every time.
+		for i := 0; i < 10; i++ {
+			newData, e := Merge([]byte(origin), false, nil, set.vals...)
+			if e != nil {
+				t.Fatal(e)
+			} else if got := strings.TrimRight(string(newData), " "); got != set.expect {
+				t.Fatalf("%v: Expected %s but got %s", set.vals, set.expect, got)
+			}
+		}
+	}
+}
+
 func TestIncrAndDecr(t *testing.T) {
 	testSet := []TestSet{
 		// int 1
@@ -1090,12 +1135,12 @@ func TestIncrAndDecr(t *testing.T) {
 			updatingVal: float32(107.1),
 			expect:      308.1,
 		},
-		/* 		{
+		{
 			path:        []interface{}{"incr", 0},
 			before:      308.1,
 			updatingVal: 100.1,
 			expect:      408.2,
-		}, */
+		},
 		// int
 		{
 			path:        []interface{}{"incr", 1},
@@ -1173,6 +1218,174 @@ func TestIncrAndDecr(t *testing.T) {
 
 }
 
+func TestNegativeIndex(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{"genre", -1}, expect: "Drama"},
+		{path: []interface{}{"genre", -4}, expect: "Fantasy"},
+		{path: []interface{}{"reviews", -1, "review", -3}, expect: "dragon die for sb's terrible writing."},
+		{path: []interface{}{"relevant", "Plot Keywords", -2}, expect: " queen "},
+		{path: []interface{}{"genre", -5}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		// the smallest int, and a huge negative index which must not be allocated for.
+		{path: []interface{}{"genre", -int(^uint(0)>>1) - 1}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		{path: []interface{}{"genre", -int(^uint(0) >> 2)}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		{path: []interface{}{"genre", -1 << 30}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		{path: []interface{}{"episodes", -1}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not a json array") == -1 }},
+	}
+	for _, set := range testSet {
+		str, e := String(jsonGetSetData, set.path...)
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatal(set.path, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(e)
+		}
+		if str != set.expect.(string) {
+			t.Logf("Expected %s but got %s", set.expect, str)
+			t.Fail()
+		}
+	}
+	if _, e := Get([]byte(`{"empty": []}`), "empty", -1); e == nil {
+		t.Fatal("Expected error of index out of range")
+	}
+
+	data := append([]byte{}, jsonGetSetData...)
+	var e error
+	if data, e = Set(data, "Sci-Fi", "genre", -1); e != nil {
+		t.Fatal(e)
+	} else if data, e = Incr(data, 1, "incr", -5); e != nil {
+		t.Fatal(e)
+	} else if data, e = Remove(data, "genre", -1); e != nil {
+		t.Fatal(e)
+	} else if data, e = Remove(data, "boola", -6); e != nil {
+		t.Fatal(e)
+	}
+	if val, e := StringArray(data, "genre"); e != nil {
+		t.Fatal(e)
+	} else if expect := []string{"Fantasy", " Action", "Adventure"}; !reflect.DeepEqual(val, expect) {
+		t.Logf("Expected %#v but got %#v", expect, val)
+		t.Fail()
+	}
+	if val, e := Int(data, "incr", 0); e != nil || val != 2 {
+		t.Fatalf("Expected 2 but got %d, %v", val, e)
+	}
+	if val, e := BoolArray(data, "boola"); e != nil {
+		t.Fatal(e)
+	} else if expect := []bool{true, false, true, true, false}; !reflect.DeepEqual(val, expect) {
+		t.Logf("Expected %#v but got %#v", expect, val)
+		t.Fail()
+	}
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	defer func(policy DuplicateKeyPolicy) { DuplicateKeys = policy }(DuplicateKeys)
+	data := `{"a": 1, "b": {"c": true}, "a": 2, "d": [], "a": 3}`
+	var testSet = []struct {
+		policy          DuplicateKeyPolicy
+		get, size       interface{}
+		toMap           map[string]interface{}
+		removed, merged string
+	}{
+		{LastKeyWins, 3, 3, map[string]interface{}{"a": 3, "b": map[string]interface{}{"c": true}, "d": []interface{}{}}, `{"b":{"c":true},"d":[]}`,
+			`{"a":1,"b":{"c":true},"a":2,"d":[],"a":0}`},
+		{FirstKeyWins, 1, 3, map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": true}, "d": []interface{}{}}, `{"b":{"c":true},"a":2,"d":[],"a":3}`,
+			`{"a":0,"b":{"c":true},"a":2,"d":[],"a":3}`},
+		{DuplicateKeyError, nil, nil, nil, "", ""},
+	}
+	isDuplicated := func(e error) bool { return e != nil && strings.Contains(e.Error(), `key "a" is duplicated`) }
+	for _, set := range testSet {
+		DuplicateKeys = set.policy
+		if val, e := Int([]byte(data), "a"); set.get == nil && !isDuplicated(e) {
+			t.Fatalf("policy %d: expected the duplicated error of Get but got %v, %v", set.policy, val, e)
+		} else if set.get != nil && (e != nil || val != set.get) {
+			t.Fatalf("policy %d: expected %v but got %v, %v", set.policy, set.get, val, e)
+		}
+		if size, e := Size([]byte(data)); set.size == nil && !isDuplicated(e) {
+			t.Fatalf("policy %d: expected the duplicated error of Size but got %v, %v", set.policy, size, e)
+		} else if set.size != nil && (e != nil || size != set.size) {
+			t.Fatalf("policy %d: expected size %v but got %v, %v", set.policy, set.size, size, e)
+		}
+		if m, e := Get([]byte(data)); set.toMap == nil && !isDuplicated(e) {
+			t.Fatalf("policy %d: expected the duplicated error of toMap but got %v, %v", set.policy, m, e)
+		} else if set.toMap != nil && (e != nil || !reflect.DeepEqual(m, set.toMap)) {
+			t.Fatalf("policy %d: expected %v but got %v, %v", set.policy, set.toMap, m, e)
+		}
+		if removed, e := Remove([]byte(data), "a"); set.removed == "" && !isDuplicated(e) {
+			t.Fatalf("policy %d: expected the duplicated error of Remove but got %s, %v", set.policy, removed, e)
+		} else if set.removed != "" && (e != nil || strings.Replace(string(removed), " ", "", -1) != set.removed) {
+			t.Fatalf("policy %d: expected %s but got %s, %v", set.policy, set.removed, removed, e)
+		}
+		if merged, e := Merge([]byte(data), false, nil, "a", 0); set.merged == "" && !isDuplicated(e) {
+			t.Fatalf("policy %d: expected the duplicated error of Merge but got %s, %v", set.policy, merged, e)
+		} else if set.merged != "" && (e != nil || strings.Replace(string(merged), " ", "", -1) != set.merged) {
+			t.Fatalf("policy %d: expected %s but got %s, %v", set.policy, set.merged, merged, e)
+		}
+		vals, errs := GetMany([]byte(data), Path("a"), Path("b", "c"))
+		if set.get == nil && !isDuplicated(errs[0]) || set.get != nil && (errs[0] != nil || vals[0] != set.get) {
+			t.Fatalf("policy %d: GetMany got %v, %v", set.policy, vals[0], errs[0])
+		} else if errs[1] != nil || vals[1] != true {
+			t.Fatalf("policy %d: GetMany got %v, %v", set.policy, vals[1], errs[1])
+		}
+	}
+	DuplicateKeys = FirstKeyWins
+	if size, e := Size([]byte(`{"a": 1, "\u0061": 2, "\"": 3}`)); e != nil || size != 2 {
+		t.Fatalf("Expected the escaped duplicated key counted once but got %v, %v", size, e)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{"genre"}, setID: 0, updatingVal: []interface{}{"Sci-Fi", 1},
+			expect: []interface{}{"Sci-Fi", 1, "Fantasy", " Action", "Adventure", "Drama"}},
+		{path: []interface{}{"genre"}, setID: 2, updatingVal: []interface{}{[]int{1, 2}},
+			expect: []interface{}{"Fantasy", " Action", []interface{}{1, 2}, "Adventure", "Drama"}},
+		{path: []interface{}{"genre"}, setID: 4, updatingVal: []interface{}{nil},
+			expect: []interface{}{"Fantasy", " Action", "Adventure", "Drama", nil}},
+		{path: []interface{}{"genre"}, setID: -1, updatingVal: []interface{}{true},
+			expect: []interface{}{"Fantasy", " Action", "Adventure", true, "Drama"}},
+		{path: []interface{}{"genre"}, setID: -4, updatingVal: []interface{}{"first"},
+			expect: []interface{}{"first", "Fantasy", " Action", "Adventure", "Drama"}},
+		{path: []interface{}{"reviews", 0, "review"}, setID: 1, updatingVal: []interface{}{map[string]interface{}{"a": 1}},
+			expect: 1, updatedPath: []interface{}{"reviews", 0, "review", 1, "a"}},
+		{path: []interface{}{PathExpr("relevant.Episodes")}, setID: 0, updatingVal: []interface{}{"x"},
+			expect: "x", updatedPath: []interface{}{"relevant", "Episodes", 0}},
+		{path: []interface{}{"genre"}, setID: 5, updatingVal: []interface{}{1}, handleErr: func(e error) bool {
+			return e == nil || e.Error() != "Error at No.2 in arguments: index 5 out of range, the len is 4"
+		}},
+		{path: []interface{}{"genre"}, setID: -5, updatingVal: []interface{}{1}, handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "out of range") == -1
+		}},
+		{path: []interface{}{"title"}, setID: 0, updatingVal: []interface{}{1}, handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "not json array") == -1
+		}},
+	}
+	for _, set := range testSet {
+		data := append([]byte{}, jsonGetSetData...)
+		data, e := Insert(data, set.setID, set.updatingVal.([]interface{}), set.path...)
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatal(set.path, set.setID, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.path, set.setID, e)
+		} else if e = Validate(data); e != nil {
+			t.Fatal(set.path, set.setID, e)
+		}
+		if set.updatedPath == nil {
+			set.updatedPath = set.path
+		}
+		if val, e := Get(data, set.updatedPath...); e != nil || !reflect.DeepEqual(val, set.expect) {
+			t.Logf("%v %d: Expected %#v but got %#v, %v", set.path, set.setID, set.expect, val, e)
+			t.Fail()
+		}
+	}
+	if data, e := Insert([]byte(`[]`), 0, []interface{}{1, 2}); e != nil || strings.TrimSpace(string(data)) != "[1,2]" {
+		t.Fatalf("Expected [1,2] but got %s, %v", data, e)
+	}
+}
+
 
 //temp
 
@@ -0,0 +1,885 @@
+package hapijson
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Match is a value matched by Query.
+type Match struct {
+	// Path is the concrete path of the value, keys in string and indexes in int,
+	// it can be passed to the getters and setters directly.
+	Path []interface{}
+	// Start and End are the offsets of the value in data, Value is data[Start:End].
+	Start, End int
+	Value      []byte
+}
+
+// Query evaluates a JSONPath expression and returns every matched value, e.g.
+//
+//	$.career[*].team                  // the team of every element of career
+//	$..team                           // all the values of key team at any depth
+//	$.career[1:3]                     // the 2nd and 3rd elements of career
+//	$.career[-1]                      // the last element of career
+//	$.career[?(@.team=='CAVS')].year  // the year of the elements whose team is CAVS
+//	$.career[?(@.year =~ /^2014/)]    // the elements whose year starts with 2014
+//
+// Supported selectors are .name, ['name'], .*, [*], [index], [index1, index2], [start:end:step],
+// ..selector for recursive descent and [?(filter)]. A filter compares relative paths which
+// start with '@' to literals or to each other with ==, !=, <, <=, >, >=, =~ (regular expression),
+// and combines them with &&, || and !. A relative path alone tests the existence of the value.
+// The values are compared in json rather than being decoded, the numbers are compared exactly, e.g. 9007199254740993
+// is larger than 9007199254740992, the objects and arrays can be compared to equal. Applying =~ to a value
+// which is not a string is an error, so is a duplicated key with DuplicateKeyError, they are returned by Query.
+//
+// The payload is walked through only once no matter how many values are matched. The values selected by an index
+// list, a slice or a list of names are in the order of the selector, e.g. $.teams[::-1] is in the reverse order,
+// the others, including the recursive descent, are in document order.
+// A name selects the key set that the getters would get when the key is duplicated, see DuplicateKeys,
+// while the wildcard and the filters select every key set of an object.
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func Query(data []byte, expr string) (matches []Match, e error) {
+	var segs []jpSegment
+	if segs, e = parseJSONPath(expr); e != nil {
+		return
+	}
+	start, end, _, vtype, e := path(data, 0)
+	if e != nil {
+		return
+	}
+	matches = []Match{}
+	if len(segs) == 0 {
+		matches = append(matches, Match{Path: []interface{}{}, Start: start, End: end, Value: data[start:end]})
+		return
+	}
+	q := &jpQuery{payload: data, segs: segs, matches: matches}
+	if e = q.walk(start, vtype, []int{0}); e != nil {
+		return nil, e
+	}
+	return q.matches, nil
+}
+
+type jpQuery struct {
+	payload []byte
+	segs    []jpSegment
+	path    []interface{}
+	matches []Match
+}
+
+// jpChild is a key set of an object or an element of an array.
+type jpChild struct {
+	key        string
+	index      int
+	start, end int
+	vtype      valType
+}
+
+// walk matches the children of the value starts at start with the segments in states,
+// states are the indexes of segments.
+func (q *jpQuery) walk(start int, vtype valType, states []int) (e error) {
+	if vtype != valObject && vtype != valArray {
+		return
+	}
+	var children []jpChild
+	if children, e = q.children(start, vtype); e != nil {
+		return
+	}
+	var winners map[string]int
+	if vtype == valObject {
+		winners = keyWinners(children)
+	}
+	var order []int
+	if seg := &q.segs[states[0]]; len(states) == 1 && !seg.recursive {
+		// only the selected children are visited, in the order of the selector.
+		if order, e = seg.selected(len(children), vtype, winners); e != nil {
+			return
+		}
+	}
+	if order == nil {
+		order = make([]int, len(children))
+		for i := range order {
+			order[i] = i
+		}
+	}
+	for _, i := range order {
+		child := children[i]
+		var matched bool
+		var next []int
+		for _, s := range states {
+			seg := &q.segs[s]
+			if seg.recursive {
+				next = appendState(next, s)
+			}
+			var ok bool
+			if ok, e = seg.match(q.payload, &child, vtype, len(children), winners); e != nil {
+				return
+			} else if !ok {
+				continue
+			}
+			if s+1 == len(q.segs) {
+				matched = true
+			} else {
+				next = appendState(next, s+1)
+			}
+		}
+		if vtype == valObject {
+			q.path = append(q.path, child.key)
+		} else {
+			q.path = append(q.path, child.index)
+		}
+		if matched {
+			q.matches = append(q.matches, Match{
+				Path:  append([]interface{}{}, q.path...),
+				Start: child.start, End: child.end, Value: q.payload[child.start:child.end],
+			})
+		}
+		if len(next) > 0 {
+			if e = q.walk(child.start, child.vtype, next); e != nil {
+				return
+			}
+		}
+		q.path = q.path[:len(q.path)-1]
+	}
+	return
+}
+
+func appendState(states []int, s int) []int {
+	for _, state := range states {
+		if state == s {
+			return states
+		}
+	}
+	return append(states, s)
+}
+
+// children lists the key sets of an object or the elements of an array.
+func (q *jpQuery) children(start int, vtype valType) (children []jpChild, e error) {
+	var key string
+	var vStart, vEnd int
+	var vType valType
+	var next, hasKey, empty bool
+	for pos, i := start+1, 0; pos < len(q.payload); i++ {
+		if vtype == valObject {
+			if pos, key, hasKey, e = nextKey(q.payload, pos, true); e != nil || !hasKey {
+				return
+			}
+		}
+		if pos, vStart, vEnd, vType, next, empty, e = nextValue(q.payload, pos); e != nil || empty && vtype == valArray {
+			return
+		}
+		children = append(children, jpChild{key: key, index: i, start: vStart, end: vEnd, vtype: vType})
+		if !next {
+			return
+		}
+		pos++
+	}
+	return nil, ErrInvalidJSONPayload
+}
+
+// keyWinners returns the index of the child that every key refers to, by DuplicateKeys,
+// it's -1 if the key is duplicated with DuplicateKeyError.
+func keyWinners(children []jpChild) (winners map[string]int) {
+	winners = make(map[string]int, len(children))
+	for i, child := range children {
+		if _, dup := winners[child.key]; !dup || DuplicateKeys == LastKeyWins {
+			winners[child.key] = i
+		} else if DuplicateKeys == DuplicateKeyError {
+			winners[child.key] = -1
+		}
+	}
+	return
+}
+
+type jpSelector int8
+
+const (
+	jpName jpSelector = iota
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+type jpSegment struct {
+	recursive bool
+	selector  jpSelector
+	names     []string
+	indexes   []int
+	slice     [3]*int // start, end, step
+	filter    jpExpr
+}
+
+// selected returns the indexes of the children selected by an index list, a slice or a list of names,
+// in the order of the selector, order is nil for the other selectors.
+func (seg *jpSegment) selected(length int, vtype valType, winners map[string]int) (order []int, e error) {
+	switch {
+	case seg.selector == jpName && vtype == valObject:
+		order = []int{}
+		for _, name := range seg.names {
+			if i, ok := winners[name]; ok && i < 0 {
+				return nil, duplicateKeyError(name)
+			} else if ok {
+				order = append(order, i)
+			}
+		}
+	case seg.selector == jpIndex && vtype == valArray:
+		order = []int{}
+		for _, index := range seg.indexes {
+			if index < 0 {
+				index += length
+			}
+			if index >= 0 && index < length {
+				order = append(order, index)
+			}
+		}
+	case seg.selector == jpSlice && vtype == valArray:
+		order = []int{}
+		lower, upper, step := seg.sliceBounds(length)
+		if step > 0 {
+			for i := lower; i < upper; i += step {
+				order = append(order, i)
+			}
+		} else {
+			for i := upper; i > lower; i += step {
+				order = append(order, i)
+			}
+		}
+	case seg.selector != jpWildcard && seg.selector != jpFilter:
+		order = []int{} // selects nothing, e.g. a name in an array.
+	}
+	return
+}
+
+func (seg *jpSegment) match(payload []byte, child *jpChild, vtype valType, length int,
+	winners map[string]int) (ok bool, e error) {
+
+	switch seg.selector {
+	case jpWildcard:
+		return true, nil
+	case jpName:
+		if vtype != valObject {
+			return
+		}
+		for _, name := range seg.names {
+			if i := winners[name]; name == child.key && i < 0 {
+				return false, duplicateKeyError(name)
+			} else if name == child.key && i == child.index {
+				return true, nil
+			}
+		}
+	case jpIndex:
+		if vtype != valArray {
+			return
+		}
+		for _, index := range seg.indexes {
+			if index < 0 {
+				index += length
+			}
+			if index == child.index {
+				return true, nil
+			}
+		}
+	case jpSlice:
+		if vtype != valArray {
+			return
+		}
+		return seg.inSlice(child.index, length), nil
+	case jpFilter:
+		var val interface{}
+		if val, e = seg.filter.eval(payload, child.start); e != nil {
+			return
+		}
+		return truthy(val), nil
+	}
+	return
+}
+
+// inSlice reports whether index is selected by [start:end:step] in an array of length.
+func (seg *jpSegment) inSlice(index, length int) bool {
+	lower, upper, step := seg.sliceBounds(length)
+	if step > 0 {
+		return index >= lower && index < upper && (index-lower)%step == 0
+	}
+	return index <= upper && index > lower && (upper-index)%(-step) == 0
+}
+
+// sliceBounds returns the bounds of [start:end:step] in an array of length as RFC 9535 does,
+// the indexes are in [lower, upper) if step is positive, otherwise in (lower, upper].
+func (seg *jpSegment) sliceBounds(length int) (lower, upper, step int) {
+	step = 1
+	if seg.slice[2] != nil {
+		step = *seg.slice[2]
+	}
+	normalize := func(i *int, def int) int {
+		if i == nil {
+			return def
+		} else if *i < 0 {
+			return *i + length
+		}
+		return *i
+	}
+	clamp := func(i, min, max int) int {
+		if i < min {
+			return min
+		} else if i > max {
+			return max
+		}
+		return i
+	}
+	if step > 0 {
+		lower = clamp(normalize(seg.slice[0], 0), 0, length)
+		upper = clamp(normalize(seg.slice[1], length), 0, length)
+		return
+	}
+	upper = clamp(normalize(seg.slice[0], length-1), -1, length-1)
+	lower = clamp(normalize(seg.slice[1], -1), -1, length-1)
+	return
+}
+
+/*********** JSONPath parsing */
+
+type jpParser struct {
+	expr string
+	pos  int
+}
+
+func (p *jpParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSONPath %q at %d: %s", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *jpParser) skipWhites() {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *jpParser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func parseJSONPath(expr string) (segs []jpSegment, e error) {
+	p := &jpParser{expr: strings.TrimSpace(expr)}
+	if !p.consume("$") {
+		return nil, p.errorf("must start with '$'")
+	}
+	if segs, e = p.segments(); e == nil && p.pos < len(p.expr) {
+		e = p.errorf("unexpected %q", p.expr[p.pos])
+	}
+	return
+}
+
+// segments parses the selectors follow the '$' or '@'.
+func (p *jpParser) segments() (segs []jpSegment, e error) {
+	for p.pos < len(p.expr) {
+		var seg jpSegment
+		if p.consume("..") {
+			seg.recursive = true
+			if p.pos < len(p.expr) && p.expr[p.pos] == '[' {
+				p.pos++
+				if e = p.bracket(&seg); e != nil {
+					return
+				}
+			} else if e = p.dotted(&seg); e != nil {
+				return
+			}
+		} else if p.consume(".") {
+			if e = p.dotted(&seg); e != nil {
+				return
+			}
+		} else if p.consume("[") {
+			if e = p.bracket(&seg); e != nil {
+				return
+			}
+		} else {
+			return
+		}
+		segs = append(segs, seg)
+	}
+	return
+}
+
+// dotted parses the name or '*' follows the '.' or '..'.
+func (p *jpParser) dotted(seg *jpSegment) error {
+	if p.consume("*") {
+		seg.selector = jpWildcard
+		return nil
+	}
+	start := p.pos
+	for ; p.pos < len(p.expr); p.pos++ {
+		if b := p.expr[p.pos]; b == '.' || b == '[' || b == ' ' || b == ')' || b == '=' || b == '!' ||
+			b == '<' || b == '>' || b == '&' || b == '|' || b == ',' || b == ']' {
+			break
+		}
+	}
+	if start == p.pos {
+		return p.errorf("missing name")
+	}
+	seg.selector, seg.names = jpName, []string{p.expr[start:p.pos]}
+	return nil
+}
+
+// bracket parses the selector in brackets, the '[' must be skipped.
+func (p *jpParser) bracket(seg *jpSegment) (e error) {
+	p.skipWhites()
+	if p.consume("*") {
+		seg.selector = jpWildcard
+	} else if p.consume("?(") {
+		seg.selector = jpFilter
+		if seg.filter, e = p.or(); e != nil {
+			return
+		}
+		if p.skipWhites(); !p.consume(")") {
+			return p.errorf("missing ')'")
+		}
+	} else if b := p.peek(); b == '\'' || b == '"' {
+		seg.selector = jpName
+		for {
+			var name string
+			if name, e = p.quoted(); e != nil {
+				return
+			}
+			seg.names = append(seg.names, name)
+			if p.skipWhites(); !p.consume(",") {
+				break
+			}
+			p.skipWhites()
+		}
+	} else if e = p.indexes(seg); e != nil {
+		return
+	}
+	if p.skipWhites(); !p.consume("]") {
+		return p.errorf("missing ']'")
+	}
+	return
+}
+
+func (p *jpParser) peek() byte {
+	if p.pos < len(p.expr) {
+		return p.expr[p.pos]
+	}
+	return 0
+}
+
+// indexes parses a list of indexes or a slice.
+func (p *jpParser) indexes(seg *jpSegment) (e error) {
+	var nums [3]*int
+	for i := 0; ; {
+		p.skipWhites()
+		if b := p.peek(); b == '-' || b >= '0' && b <= '9' {
+			start := p.pos
+			for p.pos++; p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9'; p.pos++ {
+			}
+			n, err := strconv.Atoi(p.expr[start:p.pos])
+			if err != nil {
+				return p.errorf("invalid index %q", p.expr[start:p.pos])
+			}
+			nums[i] = &n
+		}
+		p.skipWhites()
+		if p.consume(":") {
+			if seg.selector = jpSlice; i == 2 {
+				return p.errorf("too many ':' in slice")
+			}
+			i++
+			continue
+		}
+		if seg.selector == jpSlice {
+			if nums[2] != nil && *nums[2] == 0 {
+				return p.errorf("slice step cannot be zero")
+			}
+			seg.slice = nums
+			return
+		}
+		if nums[0] == nil {
+			return p.errorf("missing index")
+		}
+		seg.selector, seg.indexes = jpIndex, append(seg.indexes, *nums[0])
+		if !p.consume(",") {
+			return
+		}
+		nums[0] = nil
+	}
+}
+
+// quoted parses a string quoted by ' or ".
+func (p *jpParser) quoted() (str string, e error) {
+	quote := p.expr[p.pos]
+	var buf []byte
+	for p.pos++; p.pos < len(p.expr); p.pos++ {
+		if b := p.expr[p.pos]; b == '\\' && p.pos+1 < len(p.expr) {
+			p.pos++
+			buf = append(buf, p.expr[p.pos])
+		} else if b == quote {
+			p.pos++
+			return string(buf), nil
+		} else {
+			buf = append(buf, b)
+		}
+	}
+	return "", p.errorf("unclosed string")
+}
+
+/*********** Filter expressions */
+
+// jpExpr is a node of filter expression, eval returns a jpValue, a bool of the logical expressions,
+// or jpMissing if a relative path doesn't exist.
+type jpExpr interface {
+	eval(payload []byte, current int) (val interface{}, e error)
+}
+
+// jpValue is a json value of a relative path or a literal, it's compared in its json form rather than being
+// decoded, e.g. the numbers are compared exactly.
+type jpValue struct {
+	json  []byte
+	vtype valType
+}
+
+type jpMissingValue struct{}
+
+var jpMissing = jpMissingValue{}
+
+func truthy(val interface{}) bool {
+	switch v := val.(type) {
+	case jpMissingValue:
+		return false
+	case bool:
+		return v
+	case jpValue:
+		return v.vtype != valFalse
+	}
+	return true
+}
+
+type jpLiteral struct{ val jpValue }
+
+func (l jpLiteral) eval([]byte, int) (interface{}, error) { return l.val, nil }
+
+type jpRelPath struct{ nodes []interface{} }
+
+// eval walks from the current value to the value of the relative path, the duplicated keys are found as the
+// getters do, see DuplicateKeys.
+func (r jpRelPath) eval(payload []byte, current int) (val interface{}, e error) {
+	var start, end int
+	var vtype valType
+	if _, start, end, vtype, _, _, e = nextValue(payload, current); e != nil {
+		return
+	}
+	for _, node := range r.nodes {
+		switch node := node.(type) {
+		case string:
+			var loc location
+			var found bool
+			if vtype != valObject {
+				return jpMissing, nil
+			} else if loc, found, e = findKey(payload, start, node, ""); e != nil {
+				return
+			} else if !found {
+				return jpMissing, nil
+			}
+			start, end, vtype = loc.start, loc.end, loc.vtype
+		case int:
+			var found bool
+			if vtype != valArray {
+				return jpMissing, nil
+			} else if start, end, vtype, found, e = elementAt(payload, start, node); e != nil || !found {
+				return jpMissing, e
+			}
+		}
+	}
+	return jpValue{json: payload[start:end], vtype: vtype}, nil
+}
+
+// elementAt returns the element of index in the array starts at pos, a negative index counts from the end.
+func elementAt(payload []byte, pos, index int) (start, end int, vtype valType, found bool, e error) {
+	if index < 0 {
+		var aryLength int
+		if start, end, _, vtype, aryLength, e = nthFromEnd(payload, pos, index); e != nil {
+			return
+		}
+		return start, end, vtype, aryLength+index >= 0, nil
+	}
+	var next, empty bool
+	for pos, i := pos+1, 0; pos < len(payload); pos, i = pos+1, i+1 {
+		if pos, start, end, vtype, next, empty, e = nextValue(payload, pos); e != nil || empty {
+			return
+		} else if i == index {
+			return start, end, vtype, true, nil
+		} else if !next {
+			return
+		}
+	}
+	e = ErrInvalidJSONPayload
+	return
+}
+
+type jpNot struct{ x jpExpr }
+
+func (n jpNot) eval(payload []byte, current int) (interface{}, error) {
+	val, e := n.x.eval(payload, current)
+	return !truthy(val), e
+}
+
+type jpLogical struct {
+	and  bool
+	l, r jpExpr
+}
+
+func (l jpLogical) eval(payload []byte, current int) (interface{}, error) {
+	val, e := l.l.eval(payload, current)
+	if e != nil {
+		return nil, e
+	} else if truthy(val) != l.and { // short circuit
+		return !l.and, nil
+	}
+	if val, e = l.r.eval(payload, current); e != nil {
+		return nil, e
+	}
+	return truthy(val), nil
+}
+
+type jpCompare struct {
+	op   string
+	l, r jpExpr
+	re   *regexp.Regexp
+}
+
+func (c jpCompare) eval(payload []byte, current int) (interface{}, error) {
+	l, e := c.l.eval(payload, current)
+	if e != nil {
+		return nil, e
+	}
+	if c.re != nil {
+		if l == jpMissing {
+			return false, nil
+		} else if lv, ok := l.(jpValue); !ok || lv.vtype != valString {
+			return nil, fmt.Errorf("=~ needs a string but got %v", l)
+		} else if str, _, e := unescapeString(lv.json, 1); e != nil {
+			return nil, e
+		} else {
+			return c.re.MatchString(str), nil
+		}
+	}
+	r, e := c.r.eval(payload, current)
+	if e != nil {
+		return nil, e
+	}
+	if l == jpMissing || r == jpMissing {
+		return c.op == "!=" && l != r, nil
+	}
+	lv, lok := l.(jpValue)
+	rv, rok := r.(jpValue)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s needs json values but got %v and %v", c.op, l, r)
+	}
+	cmp, comparable, e := compareValues(lv, rv)
+	if e != nil {
+		return nil, e
+	}
+	switch c.op {
+	case "==":
+		return comparable && cmp == 0, nil
+	case "!=":
+		return !comparable || cmp != 0, nil
+	}
+	if !comparable {
+		return false, nil
+	}
+	switch c.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil // ">="
+}
+
+// String returns the value in json, for the error messages.
+func (v jpValue) String() string { return string(v.json) }
+
+// compareValues compares json values, numbers and strings are ordered, the numbers are compared exactly in
+// decimal, other types can only be compared to equal, comparable is false if l and r are different types.
+func compareValues(l, r jpValue) (cmp int, comparable bool, e error) {
+	isNumber := func(vtype valType) bool { return vtype == valNumber || vtype == valFloat }
+	if isNumber(l.vtype) && isNumber(r.vtype) {
+		var x, y decimal
+		if x, _, e = parseDecimal(l.json); e != nil {
+			return
+		} else if y, _, e = parseDecimal(r.json); e != nil {
+			return
+		}
+		return x.cmp(y), true, nil
+	} else if l.vtype != r.vtype {
+		return
+	}
+	switch l.vtype {
+	case valString:
+		var ls, rs string
+		if ls, _, e = unescapeString(l.json, 1); e != nil {
+			return
+		} else if rs, _, e = unescapeString(r.json, 1); e != nil {
+			return
+		}
+		return strings.Compare(ls, rs), true, nil
+	case valObject, valArray:
+		var equal bool
+		if equal, e = jsonEqual(l.json, r.json); e != nil || !equal {
+			return 1, false, e
+		}
+	}
+	return 0, true, nil // true, false and null
+}
+
+// or := and ('||' and)*
+func (p *jpParser) or() (x jpExpr, e error) {
+	if x, e = p.and(); e != nil {
+		return
+	}
+	for p.skipWhites(); p.consume("||"); p.skipWhites() {
+		var r jpExpr
+		if r, e = p.and(); e != nil {
+			return
+		}
+		x = jpLogical{l: x, r: r}
+	}
+	return
+}
+
+// and := unary ('&&' unary)*
+func (p *jpParser) and() (x jpExpr, e error) {
+	if x, e = p.unary(); e != nil {
+		return
+	}
+	for p.skipWhites(); p.consume("&&"); p.skipWhites() {
+		var r jpExpr
+		if r, e = p.unary(); e != nil {
+			return
+		}
+		x = jpLogical{and: true, l: x, r: r}
+	}
+	return
+}
+
+// unary := '!' unary | '(' or ')' | operand (op operand)?
+func (p *jpParser) unary() (x jpExpr, e error) {
+	p.skipWhites()
+	if p.consume("!") {
+		if x, e = p.unary(); e != nil {
+			return
+		}
+		return jpNot{x}, nil
+	}
+	if p.consume("(") {
+		if x, e = p.or(); e != nil {
+			return
+		}
+		if p.skipWhites(); !p.consume(")") {
+			return nil, p.errorf("missing ')'")
+		}
+		return
+	}
+	if x, e = p.operand(); e != nil {
+		return
+	}
+	p.skipWhites()
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if !p.consume(op) {
+			continue
+		}
+		p.skipWhites()
+		c := jpCompare{op: op, l: x}
+		if op == "=~" {
+			c.re, e = p.regexp()
+		} else {
+			c.r, e = p.operand()
+		}
+		return c, e
+	}
+	return
+}
+
+// operand := '@' segments | string | number | true | false | null
+func (p *jpParser) operand() (x jpExpr, e error) {
+	switch b := p.peek(); {
+	case b == '@':
+		p.pos++
+		var segs []jpSegment
+		if segs, e = p.segments(); e != nil {
+			return
+		}
+		rel := jpRelPath{nodes: []interface{}{}}
+		for _, seg := range segs {
+			if seg.recursive {
+				return nil, p.errorf("recursive descent is not supported in relative paths")
+			} else if seg.selector == jpName && len(seg.names) == 1 {
+				rel.nodes = append(rel.nodes, seg.names[0])
+			} else if seg.selector == jpIndex && len(seg.indexes) == 1 {
+				rel.nodes = append(rel.nodes, seg.indexes[0])
+			} else {
+				return nil, p.errorf("only names and indexes are supported in relative paths")
+			}
+		}
+		return rel, nil
+	case b == '\'' || b == '"':
+		var str string
+		str, e = p.quoted()
+		return jpLiteral{jpValue{json: []byte(`"` + escape(str) + `"`), vtype: valString}}, e
+	case b == '-' || b >= '0' && b <= '9':
+		start := p.pos
+		for p.pos++; p.pos < len(p.expr); p.pos++ {
+			if b := p.expr[p.pos]; (b < '0' || b > '9') && b != '.' && b != 'e' && b != 'E' && b != '-' && b != '+' {
+				break
+			}
+		}
+		// the number is kept in text to be compared exactly, it's checked by ParseFloat and parseDecimal.
+		number := p.expr[start:p.pos]
+		if _, err := strconv.ParseFloat(number, 64); err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+			return nil, p.errorf("invalid number %q", number)
+		} else if _, _, err = parseDecimal([]byte(number)); err != nil {
+			return nil, p.errorf("invalid number %q", number)
+		}
+		return jpLiteral{jpValue{json: []byte(number), vtype: valNumber}}, nil
+	case p.consume("true"):
+		return jpLiteral{jpValue{json: []byte("true"), vtype: valTrue}}, nil
+	case p.consume("false"):
+		return jpLiteral{jpValue{json: []byte("false"), vtype: valFalse}}, nil
+	case p.consume("null"):
+		return jpLiteral{jpValue{json: []byte("null"), vtype: valNull}}, nil
+	}
+	return nil, p.errorf("invalid operand")
+}
+
+// regexp parses /pattern/flags, only flag 'i' is supported.
+func (p *jpParser) regexp() (re *regexp.Regexp, e error) {
+	if !p.consume("/") {
+		return nil, p.errorf("regular expression must be wrapped by '/'")
+	}
+	var buf []byte
+	for ; p.pos < len(p.expr); p.pos++ {
+		if b := p.expr[p.pos]; b == '\\' && p.pos+1 < len(p.expr) && p.expr[p.pos+1] == '/' {
+			p.pos++
+			buf = append(buf, '/')
+		} else if b == '/' {
+			p.pos++
+			pattern := string(buf)
+			if p.consume("i") {
+				pattern = "(?i)" + pattern
+			}
+			if re, e = regexp.Compile(pattern); e != nil {
+				return nil, p.errorf("%v", e)
+			}
+			return
+		} else {
+			buf = append(buf, b)
+		}
+	}
+	return nil, p.errorf("unclosed regular expression")
+}
@@ -0,0 +1,166 @@
+package hapijson
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var jsonCareerData = []byte(`{
+	"name": "LBJ", "teams": ["LAL", "CAVS", "HEAT"],
+	"career": [
+		{"year": "2003-2010", "team": "CAVS", "titles": 0},
+		{"year": "2011-2014", "team": "HEAT", "titles": 2},
+		{"year": "2014-2018", "team": "CAVS", "titles": 1},
+		{"year": "2018-present", "team": "Lakers", "titles": 1, "coach": {"team": "Lakers"}}
+	]
+}`)
+
+func TestQuery(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{"$.career[*].team"}, expect: []string{`"CAVS"`, `"HEAT"`, `"CAVS"`, `"Lakers"`}},
+		{path: []interface{}{"$..team"}, expect: []string{`"CAVS"`, `"HEAT"`, `"CAVS"`, `"Lakers"`, `"Lakers"`}},
+		{path: []interface{}{"$.career[?(@.team=='CAVS')].year"}, expect: []string{`"2003-2010"`, `"2014-2018"`}},
+		{path: []interface{}{"$.career[?(@.year =~ /^2014/)].team"}, expect: []string{`"CAVS"`}},
+		{path: []interface{}{"$.career[?(@.titles > 0 && @.team != 'HEAT')].year"}, expect: []string{`"2014-2018"`, `"2018-present"`}},
+		{path: []interface{}{"$.career[?(@.coach)].year"}, expect: []string{`"2018-present"`}},
+		{path: []interface{}{"$.career[?(!@.coach)].titles"}, expect: []string{"0", "2", "1"}},
+		{path: []interface{}{"$.teams[1:3]"}, expect: []string{`"CAVS"`, `"HEAT"`}},
+		{path: []interface{}{"$.teams[::2]"}, expect: []string{`"LAL"`, `"HEAT"`}},
+		{path: []interface{}{"$.teams[-1]"}, expect: []string{`"HEAT"`}},
+		{path: []interface{}{"$.teams[0,2]"}, expect: []string{`"LAL"`, `"HEAT"`}},
+		{path: []interface{}{"$.teams[2,0]"}, expect: []string{`"HEAT"`, `"LAL"`}},
+		{path: []interface{}{"$.teams[::-1]"}, expect: []string{`"HEAT"`, `"CAVS"`, `"LAL"`}},
+		{path: []interface{}{"$.career[2:0:-1].titles"}, expect: []string{"1", "2"}},
+		{path: []interface{}{"$['teams','name']"}, expect: []string{`["LAL", "CAVS", "HEAT"]`, `"LBJ"`}},
+		{path: []interface{}{"$['name','teams'][0]"}, expect: []string{`"LAL"`}},
+		{path: []interface{}{"$.teams[?(@ == 'HEAT')]"}, expect: []string{`"HEAT"`}},
+		{path: []interface{}{"$.nothing"}, expect: []string{}},
+		{path: []interface{}{"$.name"}, expect: []string{`"LBJ"`}},
+	}
+	for _, set := range testSet {
+		matches, e := Query(jsonCareerData, set.path[0].(string))
+		if e != nil {
+			t.Fatal(e)
+		}
+		vals := []string{}
+		for _, m := range matches {
+			vals = append(vals, string(m.Value))
+		}
+		if !reflect.DeepEqual(vals, set.expect) {
+			t.Logf("%s: Expected %#v but got %#v", set.path[0], set.expect, vals)
+			t.Fail()
+		}
+	}
+
+	matches, e := Query(jsonCareerData, "$..coach.team")
+	if e != nil {
+		t.Fatal(e)
+	} else if len(matches) != 1 || !reflect.DeepEqual(matches[0].Path, []interface{}{"career", 3, "coach", "team"}) {
+		t.Fatalf("Unexpected matches %#v", matches)
+	} else if val, e := SliceOf(jsonCareerData, matches[0].Path...); e != nil || string(val) != string(matches[0].Value) ||
+		string(jsonCareerData[matches[0].Start:matches[0].End]) != `"Lakers"` {
+		t.Fatalf("Unexpected match %#v, %v", matches[0], e)
+	}
+
+	numbers := []byte(`{"s": [1, 2, 3, 4, 5]}`)
+	for expr, expect := range map[string][]string{
+		"$.s[::-2]":     {"5", "3", "1"},
+		"$.s[5:1:-1]":   {"5", "4", "3"},
+		"$.s[-1:-6:-2]": {"5", "3", "1"},
+		"$.s[1:3:-1]":   {},
+		"$.s[::-1][0]":  {},
+	} {
+		matches, e := Query(numbers, expr)
+		if e != nil {
+			t.Fatal(e)
+		}
+		vals := []string{}
+		for _, m := range matches {
+			vals = append(vals, string(m.Value))
+		}
+		if !reflect.DeepEqual(vals, expect) {
+			t.Fatalf("%s: Expected %#v but got %#v", expr, expect, vals)
+		}
+	}
+
+	// the values in filters are compared in json, the numbers exactly.
+	values := []byte(`[9007199254740993, 9007199254740992, 1.50, 2e0, "A", {"x": [1]}, {"x": [1.0], "y": null}]`)
+	for expr, expect := range map[string][]string{
+		"$[?(@ == 9007199254740993)]":       {"9007199254740993"},
+		"$[?(@ > 9007199254740992)]":        {"9007199254740993"},
+		"$[?(@ == 1.5)]":                    {"1.50"},
+		"$[?(@ >= 2 && @ < 3)]":             {"2e0"},
+		"$[?(@ < 1e-999999)]":               {},
+		"$[?(@ == '\u0041')]":               {`"A"`},
+		"$[?(@.x == @.x)]":                  {`{"x": [1]}`, `{"x": [1.0], "y": null}`},
+		"$[?(@.x[0] == 1 && !@.y)]":         {`{"x": [1]}`},
+		"$[?(@.x[-1] == 1 && @.y == null)]": {`{"x": [1.0], "y": null}`},
+	} {
+		matches, e := Query(values, expr)
+		if e != nil {
+			t.Fatal(expr, e)
+		}
+		vals := []string{}
+		for _, m := range matches {
+			vals = append(vals, string(m.Value))
+		}
+		if !reflect.DeepEqual(vals, expect) {
+			t.Fatalf("%s: Expected %#v but got %#v", expr, expect, vals)
+		}
+	}
+	if _, e := Query(values, "$[?(@ =~ /^9/)]"); e == nil {
+		t.Fatal("Expected the error of =~ on a number")
+	}
+
+	defer func(policy DuplicateKeyPolicy) { DuplicateKeys = policy }(DuplicateKeys)
+	duplicated := []byte(`{"a": 1, "b": 2, "a": 3}`)
+	for _, set := range []struct {
+		policy DuplicateKeyPolicy
+		expr   string
+		expect []string
+	}{
+		{FirstKeyWins, "$.a", []string{"1"}},
+		{LastKeyWins, "$.a", []string{"3"}},
+		{LastKeyWins, "$['a','b']", []string{"3", "2"}},
+		{LastKeyWins, "$.*", []string{"1", "2", "3"}},
+		{DuplicateKeyError, "$.b", []string{"2"}},
+		{DuplicateKeyError, "$.a", nil},
+	} {
+		DuplicateKeys = set.policy
+		matches, e := Query(duplicated, set.expr)
+		if set.expect == nil {
+			if e == nil {
+				t.Fatalf("%s: Expected error but got %#v", set.expr, matches)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(e)
+		}
+		vals := []string{}
+		for _, m := range matches {
+			vals = append(vals, string(m.Value))
+		}
+		if !reflect.DeepEqual(vals, set.expect) {
+			t.Fatalf("%s: Expected %#v but got %#v", set.expr, set.expect, vals)
+		}
+	}
+
+	for _, expr := range []string{"career", "$.teams[", "$.teams[1:2:0]", "$[?(@.a ==)]", "$[?(@.a =~ 'x')]"} {
+		if _, e := Query(jsonCareerData, expr); e == nil {
+			t.Fatalf("Expected error for %q", expr)
+		}
+	}
+	// so are the relative paths in filters.
+	for policy, expect := range map[DuplicateKeyPolicy]int{FirstKeyWins: 1, LastKeyWins: 3} {
+		DuplicateKeys = policy
+		if matches, e := Query([]byte(`[{"a": 1, "a": 3}]`), fmt.Sprintf("$[?(@.a == %d)]", expect)); e != nil || len(matches) != 1 {
+			t.Fatalf("policy %d: Expected one match but got %v, %v", policy, matches, e)
+		}
+	}
+	DuplicateKeys = DuplicateKeyError
+	if _, e := Query([]byte(`[{"a": 1, "a": 3}]`), "$[?(@.a == 1)]"); e == nil || !strings.Contains(e.Error(), "duplicated") {
+		t.Fatalf("Expected the duplicated error but got %v", e)
+	}
+}
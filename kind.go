@@ -0,0 +1,78 @@
+package hapijson
+
+// Kind is the type of a json value.
+type Kind int8
+
+const (
+	// KindUnknown means the value is not a valid json value.
+	KindUnknown Kind = iota
+	KindString
+	KindNumber
+	KindObject
+	KindArray
+	KindBool
+	KindNull
+)
+
+var kindNames = [...]string{
+	KindUnknown: "unknown",
+	KindString:  "string",
+	KindNumber:  "number",
+	KindObject:  "object",
+	KindArray:   "array",
+	KindBool:    "bool",
+	KindNull:    "null",
+}
+
+// String returns the name of the kind, e.g. "string", "object".
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return kindNames[KindUnknown]
+	}
+	return kindNames[k]
+}
+
+// kindOf maps the internal value type to Kind.
+func kindOf(vtype valType) Kind {
+	switch vtype {
+	case valString:
+		return KindString
+	case valNumber, valFloat:
+		return KindNumber
+	case valObject:
+		return KindObject
+	case valArray:
+		return KindArray
+	case valTrue, valFalse:
+		return KindBool
+	case valNull:
+		return KindNull
+	}
+	return KindUnknown
+}
+
+// Type returns the kind of the value of the last node of the pathNodes, the value is not decoded. e.g.
+//
+//	Type(data, "career", 0, "team") // KindString
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func Type(data []byte, pathNodes ...interface{}) (kind Kind, e error) {
+	var vtype valType
+	if _, _, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	}
+	return kindOf(vtype), nil
+}
+
+// Exists tells whether the last node of the pathNodes exists, it's false if any of the path nodes
+// can't be reached, e.g. a key is not found, an index is out of range or the payload is invalid.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func Exists(data []byte, pathNodes ...interface{}) bool {
+	_, _, _, _, e := path(data, 0, pathNodes...)
+	return e == nil
+}
@@ -0,0 +1,46 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeAndExists(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{}, expect: KindObject},
+		{path: []interface{}{"title"}, expect: KindString},
+		{path: []interface{}{"genre"}, expect: KindArray},
+		{path: []interface{}{"episodes"}, expect: KindNull},
+		{path: []interface{}{"liked"}, expect: KindBool},
+		{path: []interface{}{"ratings", 0}, expect: KindObject},
+		{path: []interface{}{"number of seasons"}, expect: KindNumber},
+		{path: []interface{}{"reviews", 1, "review", 3}, expect: KindNumber},
+		{path: []interface{}{"reviews", 2, "review", -1}, expect: KindNumber},
+		{path: []interface{}{"reviews", 1, "review", 6}, expect: KindBool},
+		{path: []interface{}{PathExpr("reviews[0].review[0].spoiler")}, expect: KindBool},
+		{path: []interface{}{"not exists"}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not found") == -1 }},
+		{path: []interface{}{"genre", 4}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		{path: []interface{}{"title", "x"}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not a json object") == -1 }},
+	}
+	for _, set := range testSet {
+		kind, e := Type(jsonGetSetData, set.path...)
+		if exists := Exists(jsonGetSetData, set.path...); exists != (e == nil) {
+			t.Fatalf("%v: Exists returns %v but Type returns %v", set.path, exists, e)
+		}
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatal(set.path, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(e)
+		}
+		if kind != set.expect {
+			t.Logf("%v: Expected %s but got %s", set.path, set.expect, kind)
+			t.Fail()
+		}
+	}
+	if s := Kind(100).String(); s != "unknown" {
+		t.Fatalf("Expected unknown but got %s", s)
+	}
+}
@@ -0,0 +1,131 @@
+package hapijson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// LineError is the error of a record of newline-delimited json, Line starts from 1.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// LinesReader reads records from newline-delimited json (JSON Lines), e.g.
+//
+//	lr := NewLinesReader(file)
+//	for {
+//		record, e := lr.Next()
+//		if e == io.EOF {
+//			break
+//		} else if e != nil {
+//			return e // *LineError if the record is invalid.
+//		}
+//		name, _ := String(record, "name")
+//		...
+//	}
+//
+// Blank lines are skipped, the white characters around a record, including the '\r' of "\r\n", are trimmed.
+type LinesReader struct {
+	r    *bufio.Reader
+	line int
+	buf  []byte
+}
+
+// NewLinesReader returns a LinesReader reads from r.
+func NewLinesReader(r io.Reader) *LinesReader {
+	return &LinesReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record which is checked by Validate, the error of an invalid record is a *LineError.
+// The record is a view of the internal buffer, it's valid until the next call, make a copy of it to keep it
+// or to modify it by the setters. io.EOF is returned when there is no more record.
+func (lr *LinesReader) Next() (record []byte, e error) {
+	for {
+		var line []byte
+		if line, e = lr.readLine(); e != nil && (e != io.EOF || len(line) == 0) {
+			return nil, e
+		}
+		lr.line++
+		if record = trimWhites(line); len(record) == 0 {
+			continue // blank line
+		}
+		if err := Validate(record); err != nil {
+			return nil, &LineError{Line: lr.line, Err: err}
+		}
+		return record, nil
+	}
+}
+
+// Line returns the line number of the last record returned by Next.
+func (lr *LinesReader) Line() int {
+	return lr.line
+}
+
+// readLine reads a line without the '\n', the line longer than the buffer of r is joined in lr.buf.
+func (lr *LinesReader) readLine() (line []byte, e error) {
+	if line, e = lr.r.ReadSlice('\n'); e != bufio.ErrBufferFull {
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			line = line[:len(line)-1]
+		}
+		return
+	}
+	lr.buf = append(lr.buf[:0], line...)
+	for e == bufio.ErrBufferFull {
+		line, e = lr.r.ReadSlice('\n')
+		lr.buf = append(lr.buf, line...)
+	}
+	if line = lr.buf; len(line) > 0 && line[len(line)-1] == '\n' {
+		line = line[:len(line)-1]
+	}
+	return
+}
+
+// trimWhites trims the white characters at both ends of data.
+func trimWhites(data []byte) []byte {
+	start, end := 0, len(data)
+	for ; start < end && isWhite(data[start]); start++ {
+	}
+	for ; end > start && isWhite(data[end-1]); end-- {
+	}
+	return data[start:end]
+}
+
+func isWhite(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// LinesWriter writes records as newline-delimited json (JSON Lines), every record is minified in a line.
+type LinesWriter struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+// NewLinesWriter returns a LinesWriter writes to w, Flush must be called after all the records are written.
+func NewLinesWriter(w io.Writer) *LinesWriter {
+	return &LinesWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes a record minified by Minify followed by '\n', the record is not modified.
+func (lw *LinesWriter) Write(record []byte) (e error) {
+	lw.buf = Minify(append(lw.buf[:0], trimWhites(record)...))
+	if _, e = lw.w.Write(lw.buf); e == nil {
+		e = lw.w.WriteByte('\n')
+	}
+	return
+}
+
+// Flush writes the buffered records to the underlying io.Writer.
+func (lw *LinesWriter) Flush() error {
+	return lw.w.Flush()
+}
@@ -0,0 +1,59 @@
+package hapijson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLinesReader(t *testing.T) {
+	data := "{\"name\": \"LBJ\"}\r\n\n  \r\n[1, 2]\n" + `{"a": ` + "\n\"" + strings.Repeat("x", 5000) + "\"\r\n23"
+	lr := NewLinesReader(strings.NewReader(data))
+	var records []string
+	var lines []int
+	for {
+		record, e := lr.Next()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			var le *LineError
+			if !errors.As(e, &le) || le.Line != 5 || lr.Line() != 5 {
+				t.Fatalf("Expected an error at line 5 but got %v", e)
+			}
+			continue
+		}
+		records, lines = append(records, string(record)), append(lines, lr.Line())
+	}
+	expect := []string{`{"name": "LBJ"}`, "[1, 2]", `"` + strings.Repeat("x", 5000) + `"`, "23"}
+	if !reflect.DeepEqual(records, expect) {
+		t.Logf("Expected %q but got %q", expect, records)
+		t.Fail()
+	}
+	if expect := []int{1, 4, 6, 7}; !reflect.DeepEqual(lines, expect) {
+		t.Logf("Expected lines %v but got %v", expect, lines)
+		t.Fail()
+	}
+}
+
+func TestLinesWriter(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLinesWriter(&out)
+	record := []byte("{\n  \"name\": \"L B J\",\n  \"teams\": [\"CAVS\", \"LAL\"]\n}\n")
+	origin := string(record)
+	for _, r := range [][]byte{record, []byte(" 23 ")} {
+		if e := lw.Write(r); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e := lw.Flush(); e != nil {
+		t.Fatal(e)
+	}
+	if expect := "{\"name\":\"L B J\",\"teams\":[\"CAVS\",\"LAL\"]}\n23\n"; out.String() != expect {
+		t.Fatalf("Expected %q but got %q", expect, out.String())
+	} else if string(record) != origin {
+		t.Fatalf("The record is modified: %q", record)
+	}
+}
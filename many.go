@@ -0,0 +1,290 @@
+package hapijson
+
+import (
+	"fmt"
+)
+
+// GetMany gets vals from multiple paths, it's the same as calling Get for each path but the data
+// is walked through only once, the common prefixes of paths are shared. e.g.
+//
+//	vals, errs := GetMany(data, Path("career", 0, "team"), Path("career", 1, "team"), Path(PathExpr("name")))
+//
+// vals and errs are in the same order of paths, errs[i] is not nil if paths[i] can't be got.
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func GetMany(data []byte, paths ...[]interface{}) (vals []interface{}, errs []error) {
+	vals, errs = make([]interface{}, len(paths)), make([]error, len(paths))
+	resolved := make([]bool, len(paths))
+	trie := newPathTrie()
+	for i, pathNodes := range paths {
+		var e error
+		if pathNodes, e = pathNodesOf(pathNodes); e == nil {
+			e = trie.insert(i, pathNodes)
+		}
+		if e != nil {
+			errs[i], resolved[i] = e, true
+		}
+	}
+	start, end, _, vtype, e := path(data, 0)
+	if e == nil {
+		e = trie.resolve(data, location{start: start, end: end, veryStart: start, vtype: vtype}, 0,
+			func(t *pathTrie, loc location) {
+				val, e := fromJSON(data, loc.start, loc.end, loc.vtype)
+				for _, id := range t.ids {
+					vals[id], errs[id], resolved[id] = val, e, true
+				}
+			},
+			func(t *pathTrie, e error) {
+				t.each(func(id int) { vals[id], errs[id], resolved[id] = nil, e, true })
+			})
+	}
+	if e != nil {
+		for i := range paths {
+			if !resolved[i] {
+				errs[i] = e
+			}
+		}
+	}
+	return
+}
+
+// location is where a value is in the payload, see path().
+type location struct {
+	start, end, veryStart int
+	vtype                 valType
+}
+
+// pathTrie is a prefix tree of multiple paths, so they can be resolved in one traversal.
+type pathTrie struct {
+	keys    map[string]*pathTrie
+	indexes map[int]*pathTrie
+	tokens  map[refToken]*pathTrie
+	// ids are the identities of the paths end at this node.
+	ids []int
+}
+
+func newPathTrie() *pathTrie {
+	return &pathTrie{}
+}
+
+func (t *pathTrie) insert(id int, pathNodes []interface{}) error {
+	for _, what := range pathNodes {
+		var child *pathTrie
+		if ck, ok := what.(compiledKey); ok {
+			what = ck.key
+		}
+		switch node := what.(type) {
+		case string:
+			if t.keys == nil {
+				t.keys = map[string]*pathTrie{}
+			}
+			if child = t.keys[node]; child == nil {
+				child = newPathTrie()
+				t.keys[node] = child
+			}
+		case int:
+			if t.indexes == nil {
+				t.indexes = map[int]*pathTrie{}
+			}
+			if child = t.indexes[node]; child == nil {
+				child = newPathTrie()
+				t.indexes[node] = child
+			}
+		case refToken:
+			if t.tokens == nil {
+				t.tokens = map[refToken]*pathTrie{}
+			}
+			if child = t.tokens[node]; child == nil {
+				child = newPathTrie()
+				t.tokens[node] = child
+			}
+		default:
+			return fmt.Errorf("Unsupported type %T, %v", what, what)
+		}
+		t = child
+	}
+	t.ids = append(t.ids, id)
+	return nil
+}
+
+func (t *pathTrie) hasChildren() bool {
+	return len(t.keys) > 0 || len(t.indexes) > 0 || len(t.tokens) > 0
+}
+
+// each calls fn with every id in the subtree of t.
+func (t *pathTrie) each(fn func(id int)) {
+	for _, id := range t.ids {
+		fn(id)
+	}
+	for _, child := range t.keys {
+		child.each(fn)
+	}
+	for _, child := range t.indexes {
+		child.each(fn)
+	}
+	for _, child := range t.tokens {
+		child.each(fn)
+	}
+}
+
+// trieTarget is a child of a trie node that is being searched in an object or an array.
+type trieTarget struct {
+	node    *pathTrie
+	what    interface{} // the path node, for error info
+	key     string
+	index   int
+	matched bool
+}
+
+// resolve walks through the value at loc, which t refers to, and calls found with every trie node has ids,
+// fail with every trie node can't be resolved. depth is the depth of t in the trie.
+// The returned error means the payload is invalid.
+func (t *pathTrie) resolve(payload []byte, loc location, depth int,
+	found func(t *pathTrie, loc location), fail func(t *pathTrie, e error)) (e error) {
+
+	if len(t.ids) > 0 {
+		found(t, loc)
+	}
+	if !t.hasChildren() {
+		return
+	}
+	var targets []trieTarget
+	// the opener decides what the reference tokens mean, a key or an index.
+	opener := payload[loc.start]
+	for key, child := range t.keys {
+		targets = append(targets, trieTarget{node: child, what: key, key: key})
+	}
+	for index, child := range t.indexes {
+		targets = append(targets, trieTarget{node: child, what: index, index: index})
+	}
+	for token, child := range t.tokens {
+		if what, err := token.node(opener); err != nil {
+			fail(child, err)
+		} else if key, ok := what.(string); ok {
+			targets = append(targets, trieTarget{node: child, what: key, key: key})
+		} else {
+			targets = append(targets, trieTarget{node: child, what: what, index: what.(int)})
+		}
+	}
+
+	if opener == '{' {
+		e = t.resolveObject(payload, loc, depth, targets, found, fail)
+	} else if opener == '[' {
+		e = t.resolveArray(payload, loc, depth, targets, found, fail)
+	} else {
+		for _, target := range targets {
+			if _, ok := target.what.(string); ok {
+				fail(target.node, fmt.Errorf("the value of %q is not a json object", target.what))
+			} else {
+				fail(target.node, fmt.Errorf("the value of %v is not a json array", target.what))
+			}
+		}
+	}
+	return
+}
+
+func (t *pathTrie) resolveObject(payload []byte, loc location, depth int, targets []trieTarget,
+	found func(t *pathTrie, loc location), fail func(t *pathTrie, e error)) (e error) {
+
+	var key string
+	var next, hasKey bool
+	var child location
+	for pos := loc.start + 1; pos < len(payload); pos++ {
+		veryStart := pos - 1
+		if pos, key, hasKey, e = nextKey(payload, pos, true); e != nil {
+			return
+		} else if !hasKey {
+			break
+		} else if pos, child.start, child.end, child.vtype, next, _, e = nextValue(payload, pos); e != nil {
+			return
+		}
+		child.veryStart = veryStart
+		for i := range targets {
+			target := &targets[i]
+			if target.what != key {
+				continue
+			} else if target.matched && DuplicateKeys == DuplicateKeyError {
+				fail(target.node, duplicateKeyError(key))
+				continue
+			} else if target.matched && DuplicateKeys == FirstKeyWins {
+				continue
+			}
+			// with LastKeyWins, the results of the later key overwrite the earlier ones.
+			target.matched = true
+			if e = target.node.resolve(payload, child, depth+1, found, fail); e != nil {
+				return
+			}
+		}
+		if !next {
+			break
+		}
+	}
+	for _, target := range targets {
+		if target.matched {
+			continue
+		} else if _, ok := target.what.(string); ok {
+			fail(target.node, fmt.Errorf(`Error at No.%d in arguments: key %q is not found`, depth+1, target.key))
+		} else {
+			fail(target.node, fmt.Errorf("the value of %v is not a json array", target.what))
+		}
+	}
+	return
+}
+
+func (t *pathTrie) resolveArray(payload []byte, loc location, depth int, targets []trieTarget,
+	found func(t *pathTrie, loc location), fail func(t *pathTrie, e error)) (e error) {
+
+	// only the last elements that the negative indexes may refer to are kept in a ring, see nthFromEnd.
+	least := 0
+	for _, target := range targets {
+		if _, ok := target.what.(int); ok && target.index < least {
+			least = target.index
+		}
+	}
+	var buf [8]location
+	ring := buf[:0]
+	var aryLength int
+	var next, empty bool
+	var child location
+	for pos := loc.start + 1; pos < len(payload); pos++ {
+		child.veryStart = pos - 1
+		if pos, child.start, child.end, child.vtype, next, empty, e = nextValue(payload, pos); e != nil {
+			return
+		} else if empty {
+			break
+		}
+		for i := range targets {
+			if target := &targets[i]; !target.matched && target.what == aryLength {
+				target.matched = true
+				if e = target.node.resolve(payload, child, depth+1, found, fail); e != nil {
+					return
+				}
+			}
+		}
+		if len(ring)+least < 0 { // not full yet
+			ring = append(ring, child)
+		} else if least < 0 {
+			ring[aryLength%len(ring)] = child
+		}
+		aryLength++
+		if !next {
+			break
+		}
+	}
+	for i := range targets {
+		target := &targets[i]
+		if target.matched {
+			continue
+		} else if _, ok := target.what.(int); !ok {
+			fail(target.node, fmt.Errorf("the value of %q is not a json object", target.what))
+		} else if target.index < 0 && target.index+aryLength >= 0 {
+			if e = target.node.resolve(payload, ring[(target.index+aryLength)%len(ring)], depth+1, found, fail); e != nil {
+				return
+			}
+		} else {
+			fail(target.node, fmt.Errorf(`Error at No.%d in arguments: index %d out of range, the len is %d`,
+				depth+1, target.index, aryLength))
+		}
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package hapijson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetMany(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{"title"}, expect: "Game of Thrones"},
+		{path: []interface{}{"reviews", 0, "review", 0, "vote"}, expect: "756/757"},
+		{path: []interface{}{"reviews", 0, "review", 0, "stars"}, expect: 8},
+		{path: []interface{}{"reviews", -1, "user"}, expect: "Mary come here 👄"},
+		{path: []interface{}{PathExpr("ratings[1]['TV.com']")}, expect: "9/10"},
+		{path: []interface{}{Pointer("/relevant/Episodes/0/seasons/7")}, expect: 8},
+		{path: []interface{}{"ratings", 0}, expect: map[string]interface{}{"IMDB": 9.3}},
+		{path: []interface{}{"title"}, expect: "Game of Thrones"},
+		{path: []interface{}{"not exists"}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not found") == -1 }},
+		{path: []interface{}{"genre", 4}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		{path: []interface{}{"genre", -5}, handleErr: func(e error) bool { return strings.Index(e.Error(), "out of range") == -1 }},
+		{path: []interface{}{"title", "x"}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not a json object") == -1 }},
+		{path: []interface{}{"liked", 0}, handleErr: func(e error) bool { return strings.Index(e.Error(), "not a json array") == -1 }},
+		{path: []interface{}{1.1}, handleErr: func(e error) bool { return strings.Index(e.Error(), "Unsupported type") == -1 }},
+	}
+	paths := make([][]interface{}, len(testSet))
+	for i, set := range testSet {
+		paths[i] = set.path
+	}
+	vals, errs := GetMany(jsonGetSetData, paths...)
+	for i, set := range testSet {
+		if set.handleErr != nil {
+			if set.handleErr(errs[i]) {
+				t.Fatal(set.path, errs[i])
+			}
+			continue
+		} else if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if !reflect.DeepEqual(vals[i], set.expect) {
+			t.Logf("Expected %#v but got %#v", set.expect, vals[i])
+			t.Fail()
+		}
+		// must be the same as Get
+		if val, e := Get(jsonGetSetData, set.path...); e != nil || !reflect.DeepEqual(val, vals[i]) {
+			t.Logf("Get: Expected %#v but got %#v, %v", vals[i], val, e)
+			t.Fail()
+		}
+	}
+
+	if vals, errs = GetMany(jsonGetSetData, Path()); errs[0] != nil {
+		t.Fatal(errs[0])
+	} else if _, ok := vals[0].(map[string]interface{}); !ok {
+		t.Fatalf("Expected the root object but got %#v", vals[0])
+	}
+	if _, errs = GetMany([]byte(`{"a": [1, 2], "c": [`), Path("a", 0), Path("b")); errs[0] != nil || errs[1] == nil {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	// the negative indexes share the ring of the last elements, which wraps around in a long array.
+	ary := []byte(`[0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19]`)
+	vals, errs = GetMany(ary, Path(-1), Path(-3), Path(-12), Path(-20), Path(-21), Path(-int(^uint(0)>>1)-1))
+	for i, expect := range []interface{}{19, 17, 8, 0} {
+		if errs[i] != nil || vals[i] != expect {
+			t.Fatalf("Expected %v but got %v, %v", expect, vals[i], errs[i])
+		}
+	}
+	for _, e := range errs[4:] {
+		if e == nil || strings.Index(e.Error(), "out of range") == -1 {
+			t.Fatalf("Expected out of range but got %v", e)
+		}
+	}
+}
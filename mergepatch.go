@@ -0,0 +1,130 @@
+package hapijson
+
+import (
+	"fmt"
+)
+
+// MergePatch applies a RFC 7386 JSON Merge Patch to data, e.g. json:
+//
+//	{"title": "Goodbye!", "author": {"givenName": "John", "familyName": "Doe"}, "tags": ["example", "sample"]}
+//
+// after being patched by
+//
+//	{"title": "Hello!", "author": {"familyName": null}, "tags": ["example"], "phoneNumber": "+01-123-456-7890"}
+//
+// becomes
+//
+//	{"title": "Hello!", "author": {"givenName": "John"}, "tags": ["example"],"phoneNumber":"+01-123-456-7890"}
+//
+// The objects are merged recursively, null means removing the key along with its duplicates, the other values
+// replace the old ones wholesale, including arrays. If patch is not an object, it replaces the whole data.
+// The values in the patch are set in raw bytes, the untouched parts of data are not decoded.
+//
+// Note: this function assuming data and patch are valid json data, it doesn't do checking inside...
+// See the Note part of Set().
+func MergePatch(data, patch []byte) (newData []byte, e error) {
+	var pStart, pEnd int
+	var pType valType
+	if pStart, pEnd, _, pType, e = path(patch, 0); e != nil {
+		return
+	} else if pType != valObject {
+		return append([]byte{}, patch[pStart:pEnd]...), nil
+	}
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(data, 0); e != nil {
+		return
+	} else if vtype != valObject {
+		// the target is replaced by an empty object to be merged.
+		data, _, _ = updatePayload(data, []byte("{}"), start, end, rootEndOf(data))
+		end = start + 2
+	}
+	newData, _, _, e = mergePatch(data, start, end, rootEndOf(data), patch, pStart)
+	return
+}
+
+// mergePatch merges the patch object at pStart into the object in payload at start.
+func mergePatch(payload []byte, start, end, rootEnd int, patch []byte, pStart int) (newPayload []byte,
+	newEnd, newRootEnd int, e error) {
+
+	var key string
+	var hasKey, next bool
+	var vStart, vEnd, newValEnd, offset int
+	var vtype valType
+	for pos := pStart + 1; pos < len(patch); pos++ {
+		if pos, key, hasKey, e = nextKey(patch, pos, true); e != nil {
+			return
+		} else if !hasKey {
+			break
+		} else if pos, vStart, vEnd, vtype, next, _, e = nextValue(patch, pos); e != nil {
+			return
+		}
+		var old location
+		var found bool
+		if old, found, e = findKey(payload, start, key, ""); e != nil {
+			return
+		}
+		switch {
+		case vtype == valNull:
+			if found {
+				if payload, offset, rootEnd, e = removeKey(payload, start, key, rootEnd); e != nil {
+					return
+				}
+				end += offset
+			}
+		case vtype == valObject && found && old.vtype == valObject:
+			if payload, newValEnd, rootEnd, e = mergePatch(payload, old.start, old.end, rootEnd, patch, vStart); e != nil {
+				return
+			}
+			end += newValEnd - old.end
+		case found:
+			var valJSON []byte
+			if valJSON, e = patchedValue(patch, vStart, vEnd, vtype); e != nil {
+				return
+			}
+			payload, newValEnd, rootEnd = updatePayload(payload, valJSON, old.start, old.end, rootEnd)
+			end += newValEnd - old.end
+		default:
+			var valJSON []byte
+			if valJSON, e = patchedValue(patch, vStart, vEnd, vtype); e != nil {
+				return
+			}
+			j := []byte(fmt.Sprintf(`"%s":%s}`, escape(key), string(valJSON)))
+			payload, end, rootEnd = appendJSON(payload, j, valObject, start, end, rootEnd)
+		}
+		if !next {
+			break
+		}
+	}
+	newPayload, newEnd, newRootEnd = payload, end, rootEnd
+	return
+}
+
+// removeKey removes the key from the object at start, all the duplicated keys are removed whatever DuplicateKeys is,
+// as RFC 7386 says null removes the member, offset is how much the object is shortened, in negative.
+func removeKey(payload []byte, start int, key string, rootEnd int) (newPayload []byte,
+	offset, newRootEnd int, e error) {
+
+	var locs []location
+	if locs, e = keyLocations(payload, start, key); e != nil {
+		return
+	}
+	oldRootEnd := rootEnd
+	for i := len(locs) - 1; i >= 0; i-- {
+		payload, _, rootEnd = remove(payload, locs[i].start, locs[i].end, locs[i].veryStart, rootEnd)
+	}
+	return payload, rootEnd - oldRootEnd, rootEnd, nil
+}
+
+// patchedValue returns the value of the patch at vStart, the nulls in the objects are removed, as a patch value
+// set to a new key or replacing a non-object value is merged into an empty object.
+func patchedValue(patch []byte, vStart, vEnd int, vtype valType) (valJSON []byte, e error) {
+	if vtype != valObject {
+		return patch[vStart:vEnd], nil
+	}
+	var rootEnd int
+	if valJSON, _, rootEnd, e = mergePatch([]byte("{}"), 0, 2, 2, patch, vStart); e != nil {
+		return
+	}
+	return valJSON[:rootEnd], nil
+}
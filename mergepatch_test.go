@@ -0,0 +1,44 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// the test cases of the appendix of RFC 7386.
+	var testSet = []struct {
+		data, patch, expect string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a": {"b": "c"}}`, `{"a": {"b": "d", "c": null}}`, `{"a": {"b": "d"}}`},
+		{`{"a": [{"b":"c"}]}`, `{"a": [1]}`, `{"a": [1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// more
+		{`{"title": "Goodbye!", "author": {"givenName": "John", "familyName": "Doe"}, "tags": ["example", "sample"], "content": "This will be unchanged"}`,
+			`{"title": "Hello!", "phoneNumber": "+01-555-1234", "author": {"familyName": null}, "tags": ["example"]}`,
+			`{"title": "Hello!", "author": {"givenName": "John"}, "tags": ["example"], "content": "This will be unchanged","phoneNumber":"+01-555-1234"}`},
+		{`{"a": 1, "b": {"c": 1.50e0}, "a": 2}`, `{"a": null, "b": {"d": {"e": null, "f": 0.10}}}`, `{ "b": {"c": 1.50e0,"d":{"f":0.10}}}`},
+	}
+	for _, set := range testSet {
+		newData, e := MergePatch([]byte(set.data), []byte(set.patch))
+		if e != nil {
+			t.Fatal(set.patch, e)
+		}
+		if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%s + %s: Expected %s but got %s", set.data, set.patch, set.expect, got)
+			t.Fail()
+		}
+	}
+}
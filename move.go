@@ -0,0 +1,116 @@
+package hapijson
+
+import (
+	"fmt"
+)
+
+// Copy copies the value of the from path to the to path, e.g.
+//
+//	Copy(data, Path("user", "name"), Path("profile", "displayName"))
+//
+// The value is copied in raw bytes, it's not decoded and encoded again, so the formatting of numbers and
+// the escapes of strings are kept. The value is set to the to path the same as Upsert() does, the missing
+// nodes are created.
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside...
+// See the Note part of Set().
+func Copy(data []byte, from, to []interface{}) (newData []byte, e error) {
+	var start, end int
+	if start, end, _, _, e = path(data, 0, from...); e != nil {
+		return
+	}
+	raw := append([]byte{}, data[start:end]...)
+	return upsertJSON(data, raw, to)
+}
+
+// Move moves the value of the from path to the to path, it's the same as copying the value and then removing
+// the from path, e.g. json:
+//
+//	{"user": {"name": "LBJ", "age": 35}}
+//
+// after Move(data, Path("user", "name"), Path("profile", "displayName"))
+//
+//	{"user": { "age": 35},"profile":{"displayName":"LBJ"}}
+//
+// Like Copy, the value is moved in raw bytes. The to path is resolved after the from path is removed,
+// it fails if the to path is inside the from path.
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside...
+// See the Note part of Set().
+func Move(data []byte, from, to []interface{}) (newData []byte, e error) {
+	if from, e = pathNodesOf(from); e != nil {
+		return
+	} else if to, e = pathNodesOf(to); e != nil {
+		return
+	} else if len(from) == 0 {
+		return nil, fmt.Errorf("can't move the root element")
+	}
+	var start, end int
+	if start, end, _, _, e = path(data, 0, from...); e != nil {
+		return
+	}
+	// check if the to path is the from path or inside it.
+	for i := 1; i <= len(to); i++ {
+		toStart, _, _, _, err := path(data, 0, to[:i]...)
+		if err != nil {
+			break
+		} else if toStart < start || toStart >= end {
+			continue
+		} else if i == len(to) && toStart == start {
+			return data, nil // moves to itself.
+		}
+		return nil, fmt.Errorf("can't move %v into itself", from)
+	}
+	raw := append([]byte{}, data[start:end]...)
+	if data, e = Remove(data, from...); e != nil {
+		return
+	}
+	return upsertJSON(data, raw, to)
+}
+
+// RenameKey renames the key of the last node of the pathNodes to newName, the value stays what it is, e.g. json:
+//
+//	{"user": {"name": "LBJ"}}
+//
+// after RenameKey(data, "displayName", "user", "name")
+//
+//	{"user": {"displayName": "LBJ"}}
+//
+// It fails if the last node is not a key, or newName is an existing key of the object.
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside...
+// See the Note part of Set().
+func RenameKey(data []byte, newName string, pathNodes ...interface{}) (newData []byte, e error) {
+	if pathNodes, e = pathNodesOf(pathNodes); e != nil {
+		return
+	} else if len(pathNodes) == 0 {
+		return nil, fmt.Errorf("the root element has no key")
+	}
+	last := len(pathNodes) - 1
+	var parent, veryStart int
+	var vtype valType
+	if parent, _, _, vtype, e = path(data, 0, pathNodes[:last]...); e != nil {
+		return
+	}
+	key, isKey := keyOf(pathNodes[last], vtype)
+	if !isKey {
+		return nil, fmt.Errorf("path node: %v is not a key", pathNodes[last])
+	} else if key == newName {
+		return data, nil
+	} else if _, found, err := findKey(data, parent, newName, ""); err != nil {
+		return nil, err
+	} else if found {
+		return nil, fmt.Errorf("key %q already exists", newName)
+	}
+	if _, _, veryStart, _, e = path(data, parent, pathNodes[last]); e != nil {
+		return
+	}
+	var keyStart, keyEnd int
+	if _, keyStart, keyEnd, _, e = nextRawKey(data, veryStart+1); e != nil {
+		return
+	}
+	newKey := []byte(fmt.Sprintf(`"%s"`, escape(newName)))
+	// keyStart and keyEnd are inside the quotes.
+	newData, _, _ = updatePayload(data, newKey, keyStart-1, keyEnd+1, rootEndOf(data))
+	return
+}
@@ -0,0 +1,87 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCopyAndMove(t *testing.T) {
+	const origin = `{"user": {"name": "LBJ", "age": 35, "ppg": 27.0e0}, "teams": ["CAVS", "HEAT"]}`
+	var testSet = []struct {
+		move     bool
+		from, to []interface{}
+		expect   string
+		errInfo  string
+	}{
+		{false, Path("user", "name"), Path("profile", "displayName"),
+			`{"user": {"name": "LBJ", "age": 35, "ppg": 27.0e0}, "teams": ["CAVS", "HEAT"],"profile":{"displayName":"LBJ"}}`, ""},
+		{false, Path("user", "ppg"), Path("teams", 0),
+			`{"user": {"name": "LBJ", "age": 35, "ppg": 27.0e0}, "teams": [27.0e0, "HEAT"]}`, ""},
+		{false, Path(PathExpr("teams")), Path(Pointer("/user/teams")),
+			`{"user": {"name": "LBJ", "age": 35, "ppg": 27.0e0,"teams":["CAVS", "HEAT"]}, "teams": ["CAVS", "HEAT"]}`, ""},
+		{true, Path("user", "name"), Path("profile", "displayName"),
+			`{"user": { "age": 35, "ppg": 27.0e0}, "teams": ["CAVS", "HEAT"],"profile":{"displayName":"LBJ"}}`, ""},
+		{true, Path("teams", 0), Path("teams", 1),
+			`{"user": {"name": "LBJ", "age": 35, "ppg": 27.0e0}, "teams": [ "HEAT","CAVS"]}`, ""},
+		{true, Path("user", "ppg"), Path("user", "ppg"), origin, ""},
+		{true, Path("user"), Path("user", "info"), "", "into itself"},
+		{true, Path(), Path("a"), "", "root element"},
+		{true, Path("missing"), Path("a"), "", "not found"},
+		{false, Path("user"), Path("teams", 3), "", "out of range"},
+	}
+	for _, set := range testSet {
+		data := []byte(origin)
+		var newData []byte
+		var e error
+		if set.move {
+			newData, e = Move(data, set.from, set.to)
+		} else {
+			newData, e = Copy(data, set.from, set.to)
+		}
+		if set.errInfo != "" {
+			if e == nil || strings.Index(e.Error(), set.errInfo) == -1 {
+				t.Fatalf("%v -> %v: Expected error %q but got %v", set.from, set.to, set.errInfo, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.from, set.to, e)
+		}
+		if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%v -> %v: Expected %s but got %s", set.from, set.to, set.expect, got)
+			t.Fail()
+		}
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	const origin = `{"user": {"name": "LBJ", "age": 35}, "teams": ["CAVS"]}`
+	var testSet = []TestSet{
+		{path: []interface{}{"user", "name"}, updatingVal: "displayName", expect: `{"user": {"displayName": "LBJ", "age": 35}, "teams": ["CAVS"]}`},
+		{path: []interface{}{"teams"}, updatingVal: `a"b`, expect: `{"user": {"name": "LBJ", "age": 35}, "a\"b": ["CAVS"]}`},
+		{path: []interface{}{PathExpr("user.age")}, updatingVal: "age", expect: origin},
+		{path: []interface{}{"user", "age"}, updatingVal: "name", handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "already exists") == -1
+		}},
+		{path: []interface{}{"teams", 0}, updatingVal: "x", handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "not a key") == -1
+		}},
+		{path: []interface{}{"user", "missing"}, updatingVal: "x", handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "not found") == -1
+		}},
+	}
+	for _, set := range testSet {
+		newData, e := RenameKey([]byte(origin), set.updatingVal.(string), set.path...)
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatal(set.path, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.path, e)
+		}
+		if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%v: Expected %s but got %s", set.path, set.expect, got)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package hapijson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyPatch applies a RFC 6902 JSON Patch document to data, e.g. patch:
+//
+//	[
+//		{"op": "test", "path": "/name", "value": "LBJ"},
+//		{"op": "add", "path": "/career/-", "value": {"team": "LAL"}},
+//		{"op": "move", "from": "/mvp", "path": "/awards/mvp"}
+//	]
+//
+// The operations "add", "remove", "replace", "move", "copy" and "test" are applied one by one right on the
+// bytes of data, the values in the patch are set in raw bytes, the untouched parts of data stay what they are.
+//
+// It's atomic, if any of the operations fails, including "test", data and the error are returned,
+// data is never modified, newData is a new buffer.
+//
+// Note: this function assuming data and patch are valid json data, it doesn't do checking inside.
+func ApplyPatch(data, patch []byte) (newData []byte, e error) {
+	newData = append(make([]byte, 0, len(data)+len(patch)), data...)
+	no := 0
+	e = ArrayEach(patch, func(index int, operation []byte, kind Kind) (e error) {
+		no = index + 1
+		if kind != KindObject {
+			return fmt.Errorf("operation must be a json object")
+		}
+		newData, e = applyOperation(newData, operation)
+		return
+	})
+	if e != nil {
+		if no > 0 {
+			e = fmt.Errorf("Error at No.%d operation: %v", no, e)
+		}
+		return data, e
+	}
+	return
+}
+
+// applyOperation applies a JSON Patch operation to payload.
+func applyOperation(payload, operation []byte) (newPayload []byte, e error) {
+	var op, pointer, from string
+	var value []byte
+	var pathNodes, fromNodes []interface{}
+	if op, e = String(operation, "op"); e != nil {
+		return
+	} else if pointer, e = String(operation, "path"); e != nil {
+		return
+	} else if pathNodes, e = ParsePointer(pointer); e != nil {
+		return
+	}
+	switch op {
+	case "add", "replace", "test":
+		if value, e = SliceOf(operation, "value"); e != nil {
+			return
+		}
+		// the value would be spliced into payload, it's copied as the appending may overwrite the patch.
+		value = append([]byte{}, value...)
+	case "move", "copy":
+		if from, e = String(operation, "from"); e != nil {
+			return
+		} else if fromNodes, e = ParsePointer(from); e != nil {
+			return
+		}
+		var start, end int
+		if start, end, _, _, e = path(payload, 0, fromNodes...); e != nil {
+			return
+		}
+		value = append([]byte{}, payload[start:end]...)
+	case "remove":
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op)
+	}
+
+	var start, end int
+	switch op {
+	case "add", "copy":
+		return addJSON(payload, value, pathNodes)
+	case "remove":
+		if len(pathNodes) == 0 {
+			return nil, fmt.Errorf("can't remove the root element")
+		}
+		return Remove(payload, pathNodes...)
+	case "replace":
+		if start, end, _, _, e = path(payload, 0, pathNodes...); e != nil {
+			return
+		}
+		newPayload, _, _ = updatePayload(payload, value, start, end, rootEndOf(payload))
+	case "move":
+		if pointer == from {
+			return payload, nil
+		} else if strings.HasPrefix(pointer, from+"/") {
+			return nil, fmt.Errorf("can't move %q into itself", from)
+		} else if payload, e = Remove(payload, fromNodes...); e != nil {
+			return
+		}
+		return addJSON(payload, value, pathNodes)
+	case "test":
+		if start, end, _, _, e = path(payload, 0, pathNodes...); e != nil {
+			return
+		}
+		var equal bool
+		if equal, e = jsonEqual(payload[start:end], value); e == nil && !equal {
+			e = fmt.Errorf("test failed, the value of %q is %s", pointer, payload[start:end])
+		}
+		newPayload = payload
+	}
+	return
+}
+
+// addJSON adds valJSON to the location of the pathNodes in the way of the JSON Patch "add" operation,
+// a key is added or replaced, an element is inserted, the "-" of an array means appending.
+func addJSON(payload, valJSON []byte, pathNodes []interface{}) (newPayload []byte, e error) {
+	last := len(pathNodes) - 1
+	if last < 0 { // replaces the whole document.
+		return valJSON, nil
+	}
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(payload, 0, pathNodes[:last]...); e != nil {
+		return
+	}
+	token := pathNodes[last].(refToken)
+	switch vtype {
+	case valObject:
+		var loc location
+		var found bool
+		if loc, found, e = findKey(payload, start, string(token), ""); e != nil {
+			return
+		} else if found {
+			newPayload, _, _ = updatePayload(payload, valJSON, loc.start, loc.end, rootEndOf(payload))
+			return
+		}
+		j := []byte(fmt.Sprintf(`"%s":%s}`, escape(string(token)), string(valJSON)))
+		newPayload, _, _ = appendJSON(payload, j, valObject, start, end, rootEndOf(payload))
+	case valArray:
+		var index interface{}
+		if token == "-" {
+			var aryLength int
+			if aryLength, e = size(payload, valArray, start, end); e != nil {
+				return
+			}
+			index = aryLength
+		} else if index, e = token.node('['); e != nil {
+			return
+		}
+		newPayload, e = insertJSON(payload, valJSON, start, end, index.(int), last+1)
+	default:
+		e = genNotTypeError("neither json object nor json array", pathNodes[:last])
+	}
+	return
+}
+
+// jsonEqual tells whether the json values a and b are equal, the keys of objects are unordered and found as the
+// getters do, see DuplicateKeys, the numbers are compared by their exact values, e.g. 1.0 == 1e0.
+func jsonEqual(a, b []byte) (equal bool, e error) {
+	var x, y location
+	if x.start, x.end, _, x.vtype, e = path(a, 0); e != nil {
+		return
+	} else if y.start, y.end, _, y.vtype, e = path(b, 0); e != nil {
+		return
+	}
+	return valueEqual(a, x, b, y)
+}
+
+// valueEqual tells whether the value of a at x and the value of b at y are equal, see jsonEqual.
+func valueEqual(a []byte, x location, b []byte, y location) (equal bool, e error) {
+	isNumber := func(vtype valType) bool { return vtype == valNumber || vtype == valFloat }
+	if isNumber(x.vtype) && isNumber(y.vtype) {
+		var dx, dy decimal
+		if dx, _, e = parseDecimal(a[x.start:x.end]); e != nil {
+			return
+		} else if dy, _, e = parseDecimal(b[y.start:y.end]); e != nil {
+			return
+		}
+		return dx.equal(dy), nil
+	} else if x.vtype != y.vtype {
+		return false, nil
+	}
+	switch x.vtype {
+	case valString:
+		var sx, sy string
+		if sx, _, e = unescapeString(a, x.start+1); e != nil {
+			return
+		} else if sy, _, e = unescapeString(b, y.start+1); e != nil {
+			return
+		}
+		return sx == sy, nil
+	case valArray:
+		var lx, ly location
+		var nextX, nextY, emptyX, emptyY bool
+		for px, py := x.start+1, y.start+1; ; px, py = px+1, py+1 {
+			if px, lx.start, lx.end, lx.vtype, nextX, emptyX, e = nextValue(a, px); e != nil {
+				return
+			} else if py, ly.start, ly.end, ly.vtype, nextY, emptyY, e = nextValue(b, py); e != nil {
+				return
+			} else if emptyX || emptyY {
+				return emptyX == emptyY, nil
+			} else if equal, e = valueEqual(a, lx, b, ly); e != nil || !equal {
+				return
+			} else if !nextX || !nextY {
+				return nextX == nextY, nil
+			}
+		}
+	case valObject:
+		var mx, my map[string]location
+		if mx, e = objectMembers(a, x); e != nil {
+			return
+		} else if my, e = objectMembers(b, y); e != nil {
+			return
+		} else if len(mx) != len(my) {
+			return false, nil
+		}
+		for key, lx := range mx {
+			if ly, ok := my[key]; !ok {
+				return false, nil
+			} else if equal, e = valueEqual(a, lx, b, ly); e != nil || !equal {
+				return
+			}
+		}
+		return true, nil
+	}
+	return true, nil // true, false and null
+}
+
+// objectMembers returns the locations of the values of the object of payload at loc by their keys, the duplicated
+// keys are chosen as the getters do, see DuplicateKeys.
+func objectMembers(payload []byte, loc location) (members map[string]location, e error) {
+	members = map[string]location{}
+	var key string
+	var hasKey, next bool
+	for pos := loc.start + 1; pos < len(payload); pos++ {
+		var member location
+		if pos, key, hasKey, e = nextKey(payload, pos, true); e != nil || !hasKey {
+			return
+		} else if pos, member.start, member.end, member.vtype, next, _, e = nextValue(payload, pos); e != nil {
+			return
+		}
+		if _, ok := members[key]; !ok || DuplicateKeys == LastKeyWins {
+			members[key] = member
+		} else if DuplicateKeys == DuplicateKeyError {
+			return nil, duplicateKeyError(key)
+		}
+		if !next {
+			return
+		}
+	}
+	return nil, ErrInvalidJSONPayload
+}
@@ -0,0 +1,77 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	// most of them are from the examples of RFC 6902.
+	var testSet = []struct {
+		data, patch, expect, errInfo string
+	}{
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"foo": "bar", "baz": "qux"}`, ""},
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`, ""},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`, ""},
+		{`{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`, ""},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`, ""},
+		{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`, ""},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`, ""},
+		{`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2.0}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`, ""},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`, ""},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo": ["bar", ["abc", "def"]]}`, ""},
+		{`{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10}, {"op": "copy", "from": "/~1", "path": "/a~1b"}]`,
+			`{"/": 9, "~1": 10, "a/b": 9}`, ""},
+		{`{"n": 1.50}`, `[{"op": "copy", "from": "/n", "path": "/m"}, {"op": "replace", "path": "", "value": [1]}, {"op": "add", "path": "/0", "value": 0}]`,
+			`[0, 1]`, ""},
+		{`{"n": [9007199254740993, 1.50, {"x": "\u0041", "y": null}]}`,
+			`[{"op": "test", "path": "/n", "value": [9007199254740993, 15e-1, {"y": null, "x": "A"}]}]`,
+			`{"n": [9007199254740993, 1.50, {"x": "\u0041", "y": null}]}`, ""},
+		{`{"a": 1, "a": 2}`, `[{"op": "test", "path": "", "value": {"a": 1}}]`, `{"a": 1, "a": 2}`, ""},
+		// failures
+		{`{"n": 9007199254740993}`, `[{"op": "test", "path": "/n", "value": 9007199254740992}]`, "", "test failed"},
+		{`{"n": [1, 2]}`, `[{"op": "test", "path": "/n", "value": [1, 2, 3]}]`, "", "test failed"},
+		{`{"n": {"a": 1}}`, `[{"op": "test", "path": "/n", "value": {"a": 1, "b": 1}}]`, "", "test failed"},
+		{`{"baz": "qux"}`, `[{"op": "add", "path": "/a", "value": 1}, {"op": "test", "path": "/baz", "value": "bar"}]`, "", "No.2 operation: test failed"},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, "", "not found"},
+		{`{"foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, "", "not found"},
+		{`{"foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": 1}]`, "", "not found"},
+		{`{"foo": [1]}`, `[{"op": "add", "path": "/foo/2", "value": 1}]`, "", "out of range"},
+		{`{"foo": [1]}`, `[{"op": "add", "path": "/foo/01", "value": 1}]`, "", "not an array index"},
+		{`{"foo": {"a": 1}}`, `[{"op": "move", "from": "/foo", "path": "/foo/b"}]`, "", "into itself"},
+		{`{"foo": "bar"}`, `[{"op": "invalid", "path": "/foo"}]`, "", "unknown operation"},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/foo"}]`, "", "not found"},
+	}
+	for _, set := range testSet {
+		data := []byte(set.data)
+		newData, e := ApplyPatch(data, []byte(set.patch))
+		if string(data) != set.data {
+			t.Fatalf("%s: data is modified: %s", set.patch, data)
+		}
+		if set.errInfo != "" {
+			if e == nil || strings.Index(e.Error(), set.errInfo) == -1 {
+				t.Fatalf("%s: Expected error %q but got %v", set.patch, set.errInfo, e)
+			} else if string(newData) != set.data {
+				t.Fatalf("%s: Expected the original data but got %s", set.patch, newData)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.patch, e)
+		}
+		if equal, e := jsonEqual(newData, []byte(set.expect)); e != nil || !equal {
+			t.Logf("%s: Expected %s but got %s, %v", set.patch, set.expect, newData, e)
+			t.Fail()
+		}
+	}
+	// the values are spliced in raw bytes.
+	if newData, e := ApplyPatch([]byte(`{"a": 1}`), []byte(`[{"op": "add", "path": "/b", "value": 1.50e0}]`)); e != nil ||
+		strings.TrimSpace(string(newData)) != `{"a": 1,"b":1.50e0}` {
+		t.Fatalf("Expected the raw value but got %s, %v", newData, e)
+	}
+}
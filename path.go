@@ -0,0 +1,333 @@
+package hapijson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathExpr is a path written in a string form, it can be passed as the only path node to
+// every getter and setter, e.g.
+//
+//	Get(data, PathExpr("career[0].team"))
+//
+// is the same as
+//
+//	Get(data, "career", 0, "team")
+//
+// Keys are separated by '.', indexes are wrapped by '[' and ']'. A key contains '.', '[' or ']' could be
+// escaped by '\', e.g. `first\.name`, or be quoted in brackets, e.g. `["first.name"]` or `['first.name']`.
+// An empty expression means the root element of json.
+type PathExpr string
+
+// ParsePath parses a path expression into path nodes, the keys are in string and the indexes are in int.
+// See PathExpr for the syntax.
+func ParsePath(expr string) (pathNodes []interface{}, e error) {
+	pathNodes = []interface{}{}
+	for pos := 0; pos < len(expr); {
+		switch expr[pos] {
+		case '[':
+			var node interface{}
+			if node, pos, e = parseBracket(expr, pos+1); e != nil {
+				return nil, e
+			} else if pos < len(expr) && expr[pos] != '.' && expr[pos] != '[' {
+				return nil, fmt.Errorf("invalid path expression %q: expected '.' or '[' at %d", expr, pos)
+			}
+			pathNodes = append(pathNodes, node)
+		case '.':
+			if pos++; pos == len(expr) {
+				return nil, fmt.Errorf("invalid path expression %q: ends with '.'", expr)
+			}
+			fallthrough
+		default:
+			var key string
+			if key, pos, e = parseKey(expr, pos); e != nil {
+				return nil, e
+			}
+			pathNodes = append(pathNodes, key)
+		}
+	}
+	return
+}
+
+// parseKey reads a dotted key until the next unescaped '.' or '['.
+func parseKey(expr string, pos int) (key string, newPos int, e error) {
+	var buf []byte
+	for newPos = pos; newPos < len(expr); newPos++ {
+		switch b := expr[newPos]; b {
+		case '\\':
+			if newPos++; newPos == len(expr) {
+				return "", 0, fmt.Errorf("invalid path expression %q: ends with '\\'", expr)
+			}
+			buf = append(buf, expr[newPos])
+		case '.', '[':
+			goto done
+		case ']':
+			return "", 0, fmt.Errorf("invalid path expression %q: unexpected ']' at %d", expr, newPos)
+		default:
+			buf = append(buf, b)
+		}
+	}
+done:
+	if len(buf) == 0 {
+		return "", 0, fmt.Errorf("invalid path expression %q: empty key at %d", expr, pos)
+	}
+	return string(buf), newPos, nil
+}
+
+// parseBracket reads an index or a quoted key in brackets, pos must skip the '['.
+func parseBracket(expr string, pos int) (node interface{}, newPos int, e error) {
+	if pos == len(expr) {
+		return nil, 0, fmt.Errorf("invalid path expression %q: missing ']'", expr)
+	}
+	if quote := expr[pos]; quote == '"' || quote == '\'' {
+		var buf []byte
+		for newPos = pos + 1; newPos < len(expr); newPos++ {
+			if b := expr[newPos]; b == '\\' {
+				if newPos++; newPos == len(expr) {
+					break
+				}
+				buf = append(buf, expr[newPos])
+			} else if b == quote {
+				if newPos++; newPos == len(expr) || expr[newPos] != ']' {
+					break
+				}
+				return string(buf), newPos + 1, nil
+			} else {
+				buf = append(buf, b)
+			}
+		}
+		return nil, 0, fmt.Errorf("invalid path expression %q: unclosed quoted key at %d", expr, pos)
+	}
+	for newPos = pos; newPos < len(expr) && expr[newPos] != ']'; newPos++ {
+	}
+	if newPos == len(expr) {
+		return nil, 0, fmt.Errorf("invalid path expression %q: missing ']'", expr)
+	}
+	index, err := strconv.Atoi(expr[pos:newPos])
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid path expression %q: %q is not an index", expr, expr[pos:newPos])
+	}
+	return index, newPos + 1, nil
+}
+
+// pathNodesOf unwraps the only path node, which may be a []interface{}, a PathExpr, a Pointer or
+// a *CompiledPath, into path nodes.
+func pathNodesOf(pathNodes []interface{}) ([]interface{}, error) {
+	if len(pathNodes) != 1 {
+		return pathNodes, nil
+	}
+	switch pn := pathNodes[0].(type) {
+	case []interface{}:
+		return pathNodesOf(pn)
+	case PathExpr:
+		return ParsePath(string(pn))
+	case Pointer:
+		return ParsePointer(string(pn))
+	case *CompiledPath:
+		return pn.nodes, nil
+	}
+	return pathNodes, nil
+}
+
+// Pointer is a RFC 6901 JSON Pointer, e.g. "/career/0/team", it can be passed as the only path node to
+// every getter and setter, e.g.
+//
+//	Get(data, Pointer("/career/0/team"))
+//
+// '~1' and '~0' in the reference tokens are unescaped to '/' and '~'. An empty pointer means the root
+// element of json.
+type Pointer string
+
+// refToken is a reference token of a JSON Pointer, it means a key or an index depending on
+// the json value it's applied to.
+type refToken string
+
+// node returns the key or the index that the token means on the json value starts with opener.
+func (t refToken) node(opener byte) (node interface{}, e error) {
+	if opener != '[' {
+		return string(t), nil
+	}
+	if t == "-" {
+		return nil, fmt.Errorf(`the index "-" refers to the nonexistent element after the last array element`)
+	}
+	// leading zeros are not allowed in an array index of JSON Pointer.
+	if len(t) == 0 || len(t) > 1 && t[0] == '0' {
+		return nil, fmt.Errorf("%q is not an array index", string(t))
+	}
+	for i := 0; i < len(t); i++ {
+		if t[i] < '0' || t[i] > '9' {
+			return nil, fmt.Errorf("%q is not an array index", string(t))
+		}
+	}
+	return strconv.Atoi(string(t))
+}
+
+// ParsePointer parses a JSON Pointer into path nodes. As a reference token could be either a key or an index,
+// the nodes are resolved while walking through the json data, so they are only meaningful to the functions of
+// this package.
+func ParsePointer(pointer string) (pathNodes []interface{}, e error) {
+	pathNodes = []interface{}{}
+	if pointer == "" {
+		return
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q: must start with '/'", pointer)
+	}
+	var buf []byte
+	for pos := 1; pos <= len(pointer); pos++ {
+		if pos == len(pointer) || pointer[pos] == '/' {
+			pathNodes = append(pathNodes, refToken(buf))
+			buf = buf[:0]
+			continue
+		}
+		if b := pointer[pos]; b != '~' {
+			buf = append(buf, b)
+		} else if pos++; pos < len(pointer) && pointer[pos] == '0' {
+			buf = append(buf, '~')
+		} else if pos < len(pointer) && pointer[pos] == '1' {
+			buf = append(buf, '/')
+		} else {
+			return nil, fmt.Errorf("invalid JSON Pointer %q: '~' must be followed by '0' or '1'", pointer)
+		}
+	}
+	return
+}
+
+// GetPointer gets val from the location that the JSON Pointer refers to.
+// See Get().
+func GetPointer(data []byte, pointer string) (val interface{}, e error) {
+	return Get(data, Pointer(pointer))
+}
+
+// SetPointer sets a value to the location that the JSON Pointer refers to,
+// if the last reference token is "-" of an array, val is appended to the array, "-" of an object is the key "-".
+// See Set().
+func SetPointer(data []byte, val interface{}, pointer string) (newData []byte, e error) {
+	var pathNodes []interface{}
+	if pathNodes, e = ParsePointer(pointer); e != nil {
+		return
+	}
+	if ln := len(pathNodes); ln > 0 && pathNodes[ln-1] == refToken("-") {
+		var vtype valType
+		if _, _, _, vtype, e = path(data, 0, pathNodes[:ln-1]...); e != nil {
+			return
+		} else if vtype == valArray {
+			return Append(data, pathNodes[:ln-1], val)
+		}
+	}
+	return Set(data, val, pathNodes...)
+}
+
+// RemovePointer removes the key set or the element that the JSON Pointer refers to.
+// See Remove().
+func RemovePointer(data []byte, pointer string) (newData []byte, e error) {
+	var pathNodes []interface{}
+	if pathNodes, e = ParsePointer(pointer); e != nil {
+		return
+	}
+	return Remove(data, pathNodes...)
+}
+
+// CompiledPath is a parsed path which can be passed as the only path node to every getter and setter, e.g.
+//
+//	teamPath := MustCompile("career", 0, "team") // or MustCompile(PathExpr("career[0].team"))
+//	Get(data, teamPath)
+//	Set(data, "HEAT", teamPath)
+//
+// The path nodes are checked and the keys are escaped in advance, so they are not parsed again in every call
+// and the keys are compared in bytes. A CompiledPath is immutable, it's safe to be used by multiple goroutines.
+type CompiledPath struct {
+	nodes []interface{}
+}
+
+// compiledKey is a key of CompiledPath, escaped is the key escaped by escapeKey.
+type compiledKey struct {
+	key, escaped string
+}
+
+// Compile compiles pathNodes into a CompiledPath, pathNodes could be keys and indexes,
+// or the only path node of a PathExpr or a Pointer.
+func Compile(pathNodes ...interface{}) (c *CompiledPath, e error) {
+	if pathNodes, e = pathNodesOf(pathNodes); e != nil {
+		return
+	}
+	c = &CompiledPath{nodes: make([]interface{}, len(pathNodes))}
+	for i, what := range pathNodes {
+		switch node := what.(type) {
+		case string:
+			c.nodes[i] = compiledKey{key: node, escaped: escapeKey(node)}
+		case int, refToken, compiledKey:
+			c.nodes[i] = node
+		default:
+			return nil, fmt.Errorf("Unsupported type %T, %v", what, what)
+		}
+	}
+	return
+}
+
+// MustCompile is like Compile but panics if the pathNodes can't be compiled.
+func MustCompile(pathNodes ...interface{}) *CompiledPath {
+	c, e := Compile(pathNodes...)
+	if e != nil {
+		panic(e)
+	}
+	return c
+}
+
+// String returns the path in the form of PathExpr, which could be parsed by ParsePath again. A path compiled from
+// a Pointer is returned in the form of Pointer instead, which could be parsed by ParsePointer again, as a reference
+// token means a key or an index depending on the json value, that can't be written in PathExpr.
+func (c *CompiledPath) String() string {
+	var buf strings.Builder
+	quoter := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	tokenEscaper := strings.NewReplacer("~", "~0", "/", "~1")
+	for _, what := range c.nodes {
+		switch node := what.(type) {
+		case compiledKey:
+			fmt.Fprintf(&buf, `["%s"]`, quoter.Replace(node.key))
+		case refToken:
+			fmt.Fprintf(&buf, "/%s", tokenEscaper.Replace(string(node)))
+		default:
+			fmt.Fprintf(&buf, "[%d]", node)
+		}
+	}
+	return buf.String()
+}
+
+// escapeKey escapes key in the way that most json encoders do, only '"', '\\' and control characters are escaped.
+func escapeKey(key string) string {
+	var buf []byte
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			if buf != nil {
+				buf = append(buf, c)
+			}
+			continue
+		}
+		if buf == nil {
+			buf = append(make([]byte, 0, len(key)+8), key[:i]...)
+		}
+		switch c {
+		case '"', '\\':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, '\\', 'n')
+		case '\t':
+			buf = append(buf, '\\', 't')
+		case '\r':
+			buf = append(buf, '\\', 'r')
+		case '\f':
+			buf = append(buf, '\\', 'f')
+		case '\b':
+			buf = append(buf, '\\', 'b')
+		default:
+			buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+		}
+	}
+	if buf == nil {
+		return key
+	}
+	return string(buf)
+}
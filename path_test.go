@@ -0,0 +1,224 @@
+package hapijson
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{""}, expect: []interface{}{}},
+		{path: []interface{}{"career[0].team"}, expect: []interface{}{"career", 0, "team"}},
+		{path: []interface{}{"[3].true"}, expect: []interface{}{3, "true"}},
+		{path: []interface{}{"a[1][-2]"}, expect: []interface{}{"a", 1, -2}},
+		{path: []interface{}{`first\.name.x\[0\]`}, expect: []interface{}{"first.name", "x[0]"}},
+		{path: []interface{}{`["first.name"]['it\'s'].b`}, expect: []interface{}{"first.name", "it's", "b"}},
+		{path: []interface{}{"a..b"}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{"a[b]"}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{"a[0"}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{`a["b]`}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{"a[0]b"}, handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "expected '.' or '['") == -1
+		}},
+		{path: []interface{}{`["a"]b`}, handleErr: func(e error) bool { return e == nil }},
+	}
+	for _, set := range testSet {
+		val, e := ParsePath(set.path[0].(string))
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatalf("Expected error for %q", set.path[0])
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(e)
+		}
+		if !reflect.DeepEqual(val, set.expect) {
+			t.Logf("Expected %#v but got %#v", set.expect, val)
+			t.Fail()
+		}
+	}
+}
+
+func TestPathExpr(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{"title"}, expect: "Game of Thrones"},
+		{path: []interface{}{"ratings[1]['TV.com']"}, expect: "9/10"},
+		{path: []interface{}{"reviews[0].review[0].vote"}, expect: "756/757"},
+		{path: []interface{}{`relevant.Plot Keywords[1]`}, expect: " dragon "},
+	}
+	for _, set := range testSet {
+		str, e := String(jsonGetSetData, PathExpr(set.path[0].(string)))
+		if e != nil {
+			t.Fatal(e)
+		}
+		if str != set.expect.(string) {
+			t.Logf("Expected %s but got %s", set.expect, str)
+			t.Fail()
+		}
+	}
+
+	// a path compiled from a Pointer is written as a Pointer, "-" and "0" are keys or indexes as they were.
+	for _, pointer := range []string{"/reviews/2/user", "/-/0/a~1b~0c", ""} {
+		if c := MustCompile(Pointer(pointer)); c.String() != pointer {
+			t.Fatalf("Expected String() %s but got %s", pointer, c.String())
+		} else if nodes, e := ParsePointer(c.String()); e != nil || !reflect.DeepEqual(nodes, c.nodes) {
+			t.Fatalf("Expected %v but got %v, %v", c.nodes, nodes, e)
+		}
+	}
+	data := append([]byte{}, jsonGetSetData...)
+	var e error
+	if data, e = Set(data, "Walter White", PathExpr("cast[0]['Kit Harington']")); e != nil {
+		t.Fatal(e)
+	} else if str, e := String(data, "cast", 0, "Kit Harington"); e != nil || str != "Walter White" {
+		t.Fatalf("Expected Walter White but got %q, %v", str, e)
+	}
+	if data, e = Append(data, Path(PathExpr("relevant.years")), 2010); e != nil {
+		t.Fatal(e)
+	} else if n, e := Int(data, PathExpr("relevant.years[8]")); e != nil || n != 2010 {
+		t.Fatalf("Expected 2010 but got %d, %v", n, e)
+	}
+	if data, e = Remove(data, PathExpr("cast[1]")); e != nil {
+		t.Fatal(e)
+	} else if n, e := Size(data, PathExpr("cast")); e != nil || n != 3 {
+		t.Fatalf("Expected 3 but got %d, %v", n, e)
+	}
+	if _, e = Get(data, PathExpr("cast[")); e == nil {
+		t.Fatal("Expected error of invalid path expression")
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{""}, expect: []interface{}{}},
+		{path: []interface{}{"/"}, expect: []interface{}{refToken("")}},
+		{path: []interface{}{"/career/0/team"}, expect: []interface{}{refToken("career"), refToken("0"), refToken("team")}},
+		{path: []interface{}{"/a~1b/m~0n/~01"}, expect: []interface{}{refToken("a/b"), refToken("m~n"), refToken("~1")}},
+		{path: []interface{}{"career"}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{"/a~2"}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{"/a~"}, handleErr: func(e error) bool { return e == nil }},
+	}
+	for _, set := range testSet {
+		val, e := ParsePointer(set.path[0].(string))
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatalf("Expected error for %q", set.path[0])
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(e)
+		}
+		if !reflect.DeepEqual(val, set.expect) {
+			t.Logf("Expected %#v but got %#v", set.expect, val)
+			t.Fail()
+		}
+	}
+}
+
+func TestPointer(t *testing.T) {
+	data := []byte(`{"a/b": 1, "m~n": 2, "10": {"0": "zero"}, "ary": [0, 1, 2], "": "empty"}`)
+	var testSet = []TestSet{
+		{path: []interface{}{"/a~1b"}, expect: 1},
+		{path: []interface{}{"/m~0n"}, expect: 2},
+		{path: []interface{}{"/10/0"}, expect: "zero"},
+		{path: []interface{}{"/ary/2"}, expect: 2},
+		{path: []interface{}{"/"}, expect: "empty"},
+		{path: []interface{}{"/ary/01"}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{"/ary/-"}, handleErr: func(e error) bool { return e == nil }},
+		{path: []interface{}{"/ary/3"}, handleErr: func(e error) bool { return e == nil }},
+	}
+	for _, set := range testSet {
+		val, e := GetPointer(data, set.path[0].(string))
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatalf("Expected error for %q", set.path[0])
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(e)
+		}
+		if !reflect.DeepEqual(val, set.expect) {
+			t.Logf("Expected %#v but got %#v", set.expect, val)
+			t.Fail()
+		}
+	}
+
+	var e error
+	if data, e = SetPointer(data, "one", "/ary/1"); e != nil {
+		t.Fatal(e)
+	}
+	if data, e = SetPointer(data, 3, "/ary/-"); e != nil {
+		t.Fatal(e)
+	}
+	if data, e = RemovePointer(data, "/ary/0"); e != nil {
+		t.Fatal(e)
+	}
+	if val, e := InterfaceArray(data, Pointer("/ary")); e != nil {
+		t.Fatal(e)
+	} else if expect := []interface{}{"one", 2, 3}; !reflect.DeepEqual(val, expect) {
+		t.Logf("Expected %#v but got %#v", expect, val)
+		t.Fail()
+	}
+	// "-" of an object is the member named "-".
+	if data, e = SetPointer([]byte(`{"-": 1}`), 2, "/-"); e != nil {
+		t.Fatal(e)
+	} else if got := strings.TrimRight(string(data), " "); got != `{"-": 2}` {
+		t.Fatalf("Expected the key \"-\" set but got %s", got)
+	}
+}
+
+func TestCompiledPath(t *testing.T) {
+	escapedKey := "so\"esca\tp\ned \\<quote>" + string([]byte{0xe2, 0x80, 0xa8}) + string([]byte{0xe2, 0x80, 0xa9})
+	var testSet = []TestSet{
+		{path: []interface{}{"reviews", 0, "review", 0, "vote"}, expect: "756/757"},
+		{path: []interface{}{PathExpr("ratings[1]['TV.com']")}, expect: "9/10"},
+		{path: []interface{}{Pointer("/reviews/2/user")}, expect: "Mary come here 👄"},
+		{path: []interface{}{"test merge no preserve", escapedKey}, expect: "escaped"},
+		{path: []interface{}{"genre", -1}, expect: "Drama"},
+	}
+	for _, set := range testSet {
+		c, e := Compile(set.path...)
+		if e != nil {
+			t.Fatal(e)
+		}
+		done := make(chan error)
+		for i := 0; i < 4; i++ { // compiled paths are shared by goroutines.
+			go func() {
+				str, e := String(jsonGetSetData, c)
+				if e == nil && str != set.expect.(string) {
+					e = fmt.Errorf("Expected %s but got %s", set.expect, str)
+				}
+				done <- e
+			}()
+		}
+		for i := 0; i < 4; i++ {
+			if e = <-done; e != nil {
+				t.Fatal(e)
+			}
+		}
+	}
+
+	c := MustCompile(PathExpr(`cast[0]["Kit Harington"]`))
+	if c.String() != `["cast"][0]["Kit Harington"]` {
+		t.Fatalf("Unexpected String() %s", c.String())
+	}
+	// a path compiled from a Pointer is written as a Pointer, "-" and "0" are keys or indexes as they were.
+	for _, pointer := range []string{"/reviews/2/user", "/-/0/a~1b~0c", ""} {
+		if c := MustCompile(Pointer(pointer)); c.String() != pointer {
+			t.Fatalf("Expected String() %s but got %s", pointer, c.String())
+		} else if nodes, e := ParsePointer(c.String()); e != nil || !reflect.DeepEqual(nodes, c.nodes) {
+			t.Fatalf("Expected %v but got %v, %v", c.nodes, nodes, e)
+		}
+	}
+	data := append([]byte{}, jsonGetSetData...)
+	var e error
+	if data, e = Set(data, "King in the North", c); e != nil {
+		t.Fatal(e)
+	} else if vals, errs := GetMany(data, Path(c)); errs[0] != nil || vals[0] != "King in the North" {
+		t.Fatalf("Expected King in the North but got %v, %v", vals[0], errs[0])
+	}
+	if _, e = Compile("ok", 1.1); e == nil {
+		t.Fatal("Expected error of unsupported type")
+	}
+}
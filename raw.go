@@ -0,0 +1,125 @@
+package hapijson
+
+import (
+	"fmt"
+)
+
+// SetRaw sets raw, an encoded json value, to the last node of the pathNodes as Set does, e.g.
+//
+//	SetRaw(data, []byte(`{"team": "LAL"}`), false, "career", -1)
+//
+// raw is spliced into data as it is, rather than being quoted as a string like Set does with []byte.
+// If validate is true, raw is checked by Validate() before being set.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside...
+// See the Note part of Set().
+func SetRaw(data, raw []byte, validate bool, pathNodes ...interface{}) (newData []byte, e error) {
+	if raw, e = rawJSON(raw, validate); e != nil {
+		return
+	}
+	var start, end int
+	if start, end, _, _, e = path(data, 0, pathNodes...); e != nil {
+		return
+	}
+	newData, _, _ = updatePayload(data, raw, start, end, rootEndOf(data))
+	return
+}
+
+// AppendRaw appends raws, the encoded json values, to the last node of the pathNodes which must be an array,
+// e.g.
+//
+//	AppendRaw(data, false, Path("career"), []byte(`{"team": "LAL"}`), []byte("null"))
+//
+// If validate is true, raws are checked by Validate() before being appended.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside...
+// See the Note part of Set().
+func AppendRaw(data []byte, validate bool, pathNodes []interface{}, raws ...[]byte) (newData []byte, e error) {
+	if len(raws) == 0 {
+		return data, nil
+	}
+	var j []byte
+	for i, raw := range raws {
+		if raw, e = rawJSON(raw, validate); e != nil {
+			return nil, fmt.Errorf("Error at No.%d in arguments: %v", i+1, e)
+		}
+		j = append(append(j, raw...), ',')
+	}
+	j[len(j)-1] = ']'
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valArray {
+		return nil, genNotTypeError("not json array", pathNodes)
+	}
+	newData, _, _ = appendJSON(data, j, valArray, start, end, rootEndOf(data))
+	return
+}
+
+// MergeRaw merges raw, an encoded json object, into the object of the last node of the pathNodes as Merge does,
+// e.g.
+//
+//	MergeRaw(data, []byte(`{"mvp": 4, "height": "6 ft. 8 in."}`), true, false)
+//
+// The keys of raw are merged in their order in raw, the values are spliced as they are.
+// If validate is true, raw is checked by Validate() before being merged.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json encoding, it doesn't do checking inside ...
+// See the Note part of Set().
+func MergeRaw(data, raw []byte, preserve, validate bool, pathNodes ...interface{}) (newData []byte, e error) {
+	if raw, e = rawJSON(raw, validate); e != nil {
+		return
+	} else if raw[0] != '{' {
+		return nil, fmt.Errorf("%s is not a json object", raw)
+	}
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(data, 0, pathNodes...); e != nil {
+		return
+	} else if vtype != valObject {
+		return nil, genNotTypeError("not json object", pathNodes)
+	}
+	rootEnd := rootEndOf(data)
+	var key string
+	var hasKey, next bool
+	var vStart, vEnd int
+	for pos := 1; pos < len(raw); pos++ {
+		if pos, key, hasKey, e = nextKey(raw, pos, true); e != nil {
+			return
+		} else if !hasKey {
+			break
+		} else if pos, vStart, vEnd, vtype, next, _, e = nextValue(raw, pos); e != nil {
+			return
+		}
+		// the value is copied as merging may append to it.
+		val := append([]byte{}, raw[vStart:vEnd]...)
+		if data, end, rootEnd, e = mergeJSON(data, start, end, rootEnd, preserve, key, val, vtype); e != nil {
+			return
+		}
+		if !next {
+			break
+		}
+	}
+	return data, nil
+}
+
+// rawJSON returns a copy of raw without the leading and trailing whitespaces, as raw may be a part of the data
+// being modified, it's validated if validate is true.
+func rawJSON(raw []byte, validate bool) (j []byte, e error) {
+	if validate {
+		if e = Validate(raw); e != nil {
+			return
+		}
+	}
+	if j = trimWhites(raw); len(j) == 0 {
+		return nil, fmt.Errorf("empty raw json")
+	}
+	return append([]byte{}, j...), nil
+}
@@ -0,0 +1,101 @@
+package hapijson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetRaw(t *testing.T) {
+	const origin = `{"name": "LBJ", "career": [{"team": "CAVS"}], "stats": {"ppg": 27.0}}`
+	var testSet = []TestSet{
+		{path: []interface{}{"career", 0}, updatingVal: `{"team": "HEAT", "titles": [2012, 2013]}`,
+			expect: `{"name": "LBJ", "career": [{"team": "HEAT", "titles": [2012, 2013]}], "stats": {"ppg": 27.0}}`},
+		{path: []interface{}{"stats", "ppg"}, updatingVal: " 2.71e1 ",
+			expect: `{"name": "LBJ", "career": [{"team": "CAVS"}], "stats": {"ppg": 2.71e1}}`},
+		{path: []interface{}{"name"}, updatingVal: `{"first": "LeBron"`, setID: 1, handleErr: func(e error) bool {
+			return e == nil
+		}},
+		{path: []interface{}{"missing"}, updatingVal: "1", handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "not found") == -1
+		}},
+		{path: []interface{}{"name"}, updatingVal: " ", handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "empty") == -1
+		}},
+	}
+	for _, set := range testSet {
+		newData, e := SetRaw([]byte(origin), []byte(set.updatingVal.(string)), set.setID == 1, set.path...)
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatal(set.path, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.path, e)
+		}
+		if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%v: Expected %s but got %s", set.path, set.expect, got)
+			t.Fail()
+		}
+	}
+
+	// raw is a part of data itself.
+	data := []byte(`{"a": [1, 2], "b": {"c": "xxxxxxxxxx"}}`)
+	raw, e := SliceOf(data, "b")
+	if e != nil {
+		t.Fatal(e)
+	} else if data, e = SetRaw(data, raw, false, "a"); e != nil {
+		t.Fatal(e)
+	} else if got := strings.TrimRight(string(data), " "); got != `{"a": {"c": "xxxxxxxxxx"}, "b": {"c": "xxxxxxxxxx"}}` {
+		t.Fatalf("Expected the sliced value set but got %s", got)
+	}
+}
+
+func TestAppendRaw(t *testing.T) {
+	data, e := AppendRaw([]byte(`{"a": [1]}`), true, Path("a"), []byte(`{"b": 2}`), []byte(" null"))
+	if e != nil {
+		t.Fatal(e)
+	} else if got := strings.TrimRight(string(data), " "); got != `{"a": [1,{"b": 2},null]}` {
+		t.Fatalf("Expected the raw values appended but got %s", got)
+	}
+	if _, e = AppendRaw([]byte(`{"a": [1]}`), true, Path("a"), []byte("1"), []byte("[")); e == nil ||
+		strings.Index(e.Error(), "No.2") == -1 {
+		t.Fatalf("Expected the error of No.2 but got %v", e)
+	} else if _, e = AppendRaw([]byte(`{"a": {}}`), false, Path("a"), []byte("1")); e == nil {
+		t.Fatal("Expected the not array error")
+	}
+}
+
+func TestMergeRaw(t *testing.T) {
+	const origin = `{"name": "LBJ", "height": 2.04, "teams": ["CAVS"]}`
+	var testSet = []struct {
+		raw      string
+		preserve bool
+		expect   string
+	}{
+		{`{"mvp": 4, "height": "6 ft. 8 in.", "teams": ["HEAT", "LAL"]}`, false,
+			`{"name": "LBJ", "height": "6 ft. 8 in.", "teams": ["HEAT", "LAL"],"mvp":4}`},
+		{`{"mvp": 4, "height": "6 ft. 8 in.", "teams": "LAL", "name": [1.0, {}]}`, true,
+			`{"name": ["LBJ",1.0, {}], "height": [2.04,"6 ft. 8 in."], "teams": ["CAVS","LAL"],"mvp":4}`},
+		{`{}`, true, origin},
+		{`{"teams": [ ]}`, true, origin},
+	}
+	for _, set := range testSet {
+		newData, e := MergeRaw([]byte(origin), []byte(set.raw), set.preserve, true)
+		if e != nil {
+			t.Fatal(set.raw, e)
+		} else if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%s: Expected %s but got %s", set.raw, set.expect, got)
+			t.Fail()
+		}
+	}
+	// an empty array appends nothing with preserve, so does Merge.
+	if newData, e := Merge([]byte(origin), true, nil, "teams", []interface{}{}); e != nil {
+		t.Fatal(e)
+	} else if got := strings.TrimRight(string(newData), " "); got != origin {
+		t.Fatalf("Expected %s but got %s", origin, got)
+	}
+	if _, e := MergeRaw([]byte(origin), []byte(`[1]`), false, false); e == nil ||
+		strings.Index(e.Error(), "not a json object") == -1 {
+		t.Fatalf("Expected the not object error but got %v", e)
+	}
+}
@@ -0,0 +1,189 @@
+package hapijson
+
+import (
+	"io"
+)
+
+// TokenType is the type of a Token.
+type TokenType int8
+
+const (
+	TokenBeginObject TokenType = iota + 1 // {
+	TokenEndObject                        // }
+	TokenBeginArray                       // [
+	TokenEndArray                         // ]
+	TokenKey                              // a key of object, quoted
+	TokenString                           // a string value, quoted
+	TokenNumber
+	TokenBool
+	TokenNull
+)
+
+var tokenTypeNames = [...]string{
+	TokenBeginObject: "begin object",
+	TokenEndObject:   "end object",
+	TokenBeginArray:  "begin array",
+	TokenEndArray:    "end array",
+	TokenKey:         "key",
+	TokenString:      "string",
+	TokenNumber:      "number",
+	TokenBool:        "bool",
+	TokenNull:        "null",
+}
+
+// String returns the name of the token type.
+func (t TokenType) String() string {
+	if t <= 0 || int(t) >= len(tokenTypeNames) {
+		return "unknown"
+	}
+	return tokenTypeNames[t]
+}
+
+// Token is a token of json data.
+type Token struct {
+	Type TokenType
+	// Value is the slice of the token in the data, it's not copied, the quotes of keys and strings are kept and
+	// they are not unescaped.
+	Value []byte
+	// Start and End are the offsets of the token in the data, Value is data[Start:End].
+	Start, End int
+	// Depth is how many objects and arrays the token is in, the braces and brackets have the depth of
+	// their object or array, e.g. the root element is at depth 0.
+	Depth int
+}
+
+// status of the tokenizer, besides the ones of validate().
+const statusColon = ':' // a key is read, ':' is expected.
+
+// Tokenizer reads json data token by token, e.g.
+//
+//	tokenizer := NewTokenizer(data)
+//	for {
+//		token, e := tokenizer.Next()
+//		if e == io.EOF {
+//			break
+//		} else if e != nil {
+//			return e
+//		}
+//		...
+//	}
+//
+// The data is checked as it's read, the same as Validate does.
+type Tokenizer struct {
+	data []byte
+	pos  int
+	// containers are the openers of the objects and arrays the tokenizer is in.
+	containers []byte
+	status     byte
+	e          error
+}
+
+// NewTokenizer returns a Tokenizer reads data.
+func NewTokenizer(data []byte) *Tokenizer {
+	return &Tokenizer{data: data, status: statusVal}
+}
+
+// Depth returns the depth of the next token except the closing brace or bracket.
+func (t *Tokenizer) Depth() int {
+	return len(t.containers)
+}
+
+// Next returns the next token, io.EOF is returned when all the data is read.
+// The error of invalid data is returned by all the following calls.
+func (t *Tokenizer) Next() (token Token, e error) {
+	if t.e != nil {
+		return token, t.e
+	}
+	data, pos := t.data, t.pos
+	for ; pos < len(data); pos++ {
+		if b := data[pos]; b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			break
+		}
+	}
+	if pos == len(data) {
+		if len(t.containers) == 0 && t.status == statusValEnd {
+			t.e = io.EOF
+		} else {
+			t.e = getErrorInfo(data, pos)
+		}
+		return token, t.e
+	}
+	var container byte
+	if len(t.containers) > 0 {
+		container = t.containers[len(t.containers)-1]
+	}
+	token.Start, token.Depth = pos, len(t.containers)
+	switch b := data[pos]; b {
+	case '{', '[':
+		if t.status != statusVal && t.status != statusAry {
+			goto fail
+		}
+		token.Type, t.status = TokenBeginObject, statusObj
+		if b == '[' {
+			token.Type, t.status = TokenBeginArray, statusAry
+		}
+		t.containers = append(t.containers, b)
+		pos++
+	case '}', ']':
+		if b == '}' && (container != '{' || t.status != statusObj && t.status != statusValEnd) ||
+			b == ']' && (container != '[' || t.status != statusAry && t.status != statusValEnd) {
+			goto fail
+		}
+		token.Type, t.status = TokenEndObject, statusValEnd
+		if b == ']' {
+			token.Type = TokenEndArray
+		}
+		t.containers = t.containers[:len(t.containers)-1]
+		token.Depth = len(t.containers)
+		pos++
+	case ',':
+		if t.status != statusValEnd || container == 0 {
+			goto fail
+		}
+		if t.status = statusVal; container == '{' {
+			t.status = statusKey
+		}
+		t.pos = pos + 1
+		return t.Next()
+	case ':':
+		if t.status != statusColon {
+			goto fail
+		}
+		t.status, t.pos = statusVal, pos+1
+		return t.Next()
+	case '"':
+		var ok bool
+		if t.status != statusObj && t.status != statusKey && t.status != statusVal && t.status != statusAry {
+			goto fail
+		} else if pos, ok = scanString(data, pos); !ok {
+			goto fail
+		}
+		pos++ // the closing quote
+		if t.status == statusObj || t.status == statusKey {
+			token.Type, t.status = TokenKey, statusColon
+		} else {
+			token.Type, t.status = TokenString, statusValEnd
+		}
+	default:
+		var ok bool
+		var vtype valType
+		if t.status != statusVal && t.status != statusAry {
+			goto fail
+		} else if pos, vtype, ok = scanScalar(data, pos); !ok {
+			goto fail
+		}
+		switch t.status = statusValEnd; vtype {
+		case valNumber:
+			token.Type = TokenNumber
+		case valTrue, valFalse:
+			token.Type = TokenBool
+		default:
+			token.Type = TokenNull
+		}
+	}
+	token.End, token.Value, t.pos = pos, data[token.Start:pos], pos
+	return
+fail:
+	t.e = getErrorInfo(data, pos)
+	return Token{}, t.e
+}
@@ -0,0 +1,85 @@
+package hapijson
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizer(t *testing.T) {
+	data := []byte(`{"name": "LBJ", "teams": ["CAVS", {"MIA": 2}], "mvp": 4.0, "retired": false, "coach": null}`)
+	type expectToken struct {
+		Type  TokenType
+		Value string
+		Depth int
+	}
+	expect := []expectToken{
+		{TokenBeginObject, "{", 0},
+		{TokenKey, `"name"`, 1}, {TokenString, `"LBJ"`, 1},
+		{TokenKey, `"teams"`, 1}, {TokenBeginArray, "[", 1},
+		{TokenString, `"CAVS"`, 2}, {TokenBeginObject, "{", 2},
+		{TokenKey, `"MIA"`, 3}, {TokenNumber, "2", 3},
+		{TokenEndObject, "}", 2}, {TokenEndArray, "]", 1},
+		{TokenKey, `"mvp"`, 1}, {TokenNumber, "4.0", 1},
+		{TokenKey, `"retired"`, 1}, {TokenBool, "false", 1},
+		{TokenKey, `"coach"`, 1}, {TokenNull, "null", 1},
+		{TokenEndObject, "}", 0},
+	}
+	var got []expectToken
+	tokenizer := NewTokenizer(data)
+	for {
+		token, e := tokenizer.Next()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			t.Fatal(e)
+		}
+		if string(data[token.Start:token.End]) != string(token.Value) {
+			t.Fatalf("The offsets %d:%d don't match with %s", token.Start, token.End, token.Value)
+		}
+		got = append(got, expectToken{token.Type, string(token.Value), token.Depth})
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Logf("Expected %v but got %v", expect, got)
+		t.Fail()
+	}
+	if _, e := tokenizer.Next(); e != io.EOF {
+		t.Fatalf("Expected io.EOF again but got %v", e)
+	}
+
+	tokenize := func(j string) (e error) {
+		tokenizer := NewTokenizer([]byte(j))
+		for e == nil {
+			_, e = tokenizer.Next()
+		}
+		if e == io.EOF {
+			e = nil
+		}
+		return
+	}
+	for i, j := range jsonValidTestSet {
+		if e := tokenize(j); e != nil {
+			t.Fatalf("NO.%d json %q: %v", i, j, e)
+		}
+	}
+	for i, j := range jsonInvalidTestSet {
+		if e := tokenize(j); e == nil {
+			t.Fatalf("NO.%d json %q: expected failed but success", i, j)
+		} else if strings.Index(e.Error(), "line:") == -1 {
+			t.Fatalf("NO.%d json %q: expected the error info of line but got %v", i, j, e)
+		}
+	}
+
+	tokenizer = NewTokenizer([]byte("{\"a\": 1,\n \"b\" 2}"))
+	for i := 0; i < 4; i++ { // {, "a", 1 and "b"
+		if _, e := tokenizer.Next(); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if _, e := tokenizer.Next(); e == nil || strings.Index(e.Error(), "line: 2") == -1 {
+		t.Fatalf("Expected an error at line 2 but got %v", e)
+	} else if _, e2 := tokenizer.Next(); e2 != e {
+		t.Fatalf("Expected the same error but got %v", e2)
+	}
+}
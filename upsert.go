@@ -0,0 +1,136 @@
+package hapijson
+
+import (
+	"fmt"
+)
+
+// Upsert sets a value to the last node of the pathNodes like Set does, but the missing nodes are created
+// instead of failing, a key is created as an object and an index is created as an array, e.g. json:
+//
+//	{"name": "LBJ", "career": [{"team": "CAVS"}]}
+//
+// after
+//
+//	Upsert(data, 2003, "draft", "year")
+//	Upsert(data, "HEAT", "career", 1, "team")
+//
+// becomes
+//
+//	{"name": "LBJ", "career": [{"team": "CAVS"},{"team":"HEAT"}],"draft":{"year":2003}}
+//
+// An index is created only if it's the length of the array, which means appending an element, so is the "-"
+// of a JSON Pointer. The missing nodes are appended to the ends of their objects or arrays,
+// the rest of data stays what it is.
+//
+// pathNodes left empty means get to the root element of json
+//
+// Note: this function assuming data is a valid json payload, it doesn't do checking inside.
+// See the Note part of Set().
+func Upsert(data []byte, val interface{}, pathNodes ...interface{}) (newData []byte, e error) {
+	var valJSON []byte
+	if valJSON, _, e = toJSON(val); e != nil {
+		return
+	}
+	return upsertJSON(data, valJSON, pathNodes)
+}
+
+// upsertJSON sets valJSON to the last node of the pathNodes, the missing nodes are created, see Upsert.
+func upsertJSON(payload, valJSON []byte, pathNodes []interface{}) (newPayload []byte, e error) {
+	if pathNodes, e = pathNodesOf(pathNodes); e != nil {
+		return
+	}
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(payload, 0); e != nil {
+		return
+	}
+	rootEnd := rootEndOf(payload)
+	for i, what := range pathNodes {
+		if ck, ok := what.(compiledKey); ok {
+			what = ck.key
+		}
+		if key, isKey := keyOf(what, vtype); isKey {
+			var loc location
+			var found bool
+			if loc, found, e = findKey(payload, start, key, ""); e != nil {
+				return
+			} else if found {
+				start, end, vtype = loc.start, loc.end, loc.vtype
+				continue
+			}
+			var j []byte
+			if j, e = containersOf(i+2, pathNodes[i+1:], valJSON); e != nil {
+				return
+			}
+			j = []byte(fmt.Sprintf(`"%s":%s}`, escape(key), string(j)))
+			newPayload, _, _ = appendJSON(payload, j, valObject, start, end, rootEnd)
+			return
+		}
+		if s, en, _, vt, err := path(payload, start, what); err == nil {
+			start, end, vtype = s, en, vt
+			continue
+		} else if vtype == valArray && isAppending(payload, start, end, what) {
+			var j []byte
+			if j, e = containersOf(i+2, pathNodes[i+1:], valJSON); e != nil {
+				return
+			}
+			newPayload, _, _ = appendJSON(payload, append(j, ']'), valArray, start, end, rootEnd)
+			return
+		}
+		// gets the error info with the right number of the node.
+		_, _, _, _, e = path(payload, 0, pathNodes[:i+1]...)
+		return
+	}
+	newPayload, _, _ = updatePayload(payload, valJSON, start, end, rootEnd)
+	return
+}
+
+// isAppending tells whether the path node what means the element after the last one of the array,
+// it counts the elements, so it should be called only if the node is not found.
+func isAppending(payload []byte, start, end int, what interface{}) bool {
+	if token, ok := what.(refToken); ok {
+		if token == "-" {
+			return true
+		} else if node, e := token.node('['); e != nil {
+			return false
+		} else {
+			what = node
+		}
+	}
+	index, ok := what.(int)
+	if !ok || index < 0 {
+		return false
+	}
+	length, e := size(payload, valArray, start, end)
+	return e == nil && index == length
+}
+
+// containersOf makes the json of the objects and arrays which contain valJSON along the pathNodes,
+// no is the number of the first path node in the arguments.
+func containersOf(no int, pathNodes []interface{}, valJSON []byte) (j []byte, e error) {
+	j = valJSON
+	for i := len(pathNodes) - 1; i >= 0; i-- {
+		what := pathNodes[i]
+		if ck, ok := what.(compiledKey); ok {
+			what = ck.key
+		}
+		switch node := what.(type) {
+		case string:
+			j = []byte(fmt.Sprintf(`{"%s":%s}`, escape(node), string(j)))
+		case refToken:
+			if node == "-" {
+				j = []byte(fmt.Sprintf(`[%s]`, string(j)))
+			} else {
+				j = []byte(fmt.Sprintf(`{"%s":%s}`, escape(string(node)), string(j)))
+			}
+		case int:
+			if node != 0 {
+				return nil, fmt.Errorf(`Error at No.%d in arguments: index %d out of range, the len is 0`, no+i, node)
+			}
+			j = []byte(fmt.Sprintf(`[%s]`, string(j)))
+		default:
+			return nil, fmt.Errorf("Unsupported type %T, %v", what, what)
+		}
+	}
+	return
+}
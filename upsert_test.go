@@ -0,0 +1,58 @@
+package hapijson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpsert(t *testing.T) {
+	var testSet = []TestSet{
+		{path: []interface{}{"name"}, updatingVal: "King James", expect: `{"name": "King James", "career": [{"team": "CAVS"}], "empty": {}}`},
+		{path: []interface{}{"draft", "year"}, updatingVal: 2003,
+			expect: `{"name": "LBJ", "career": [{"team": "CAVS"}], "empty": {},"draft":{"year":2003}}`},
+		{path: []interface{}{"career", 1, "team"}, updatingVal: "HEAT",
+			expect: `{"name": "LBJ", "career": [{"team": "CAVS"},{"team":"HEAT"}], "empty": {}}`},
+		{path: []interface{}{"career", 0, "rings", 0}, updatingVal: 2012,
+			expect: `{"name": "LBJ", "career": [{"team": "CAVS","rings":[2012]}], "empty": {}}`},
+		{path: []interface{}{"empty", "a", "b"}, updatingVal: true, expect: `{"name": "LBJ", "career": [{"team": "CAVS"}], "empty": {"a":{"b":true}}}`},
+		{path: []interface{}{Pointer("/career/-/team")}, updatingVal: "LAL", updatedPath: []interface{}{"career", 1, "team"},
+			expect: `{"name": "LBJ", "career": [{"team": "CAVS"},{"team":"LAL"}], "empty": {}}`},
+		{path: []interface{}{PathExpr(`new["a.b"][0]`)}, updatingVal: nil,
+			expect: `{"name": "LBJ", "career": [{"team": "CAVS"}], "empty": {},"new":{"a.b":[null]}}`},
+		{path: []interface{}{"career", -1, "team"}, updatingVal: "MIA", expect: `{"name": "LBJ", "career": [{"team": "MIA"}], "empty": {}}`},
+		{path: []interface{}{"career", 2}, updatingVal: 1, handleErr: func(e error) bool {
+			return e == nil || e.Error() != "Error at No.2 in arguments: index 2 out of range, the len is 1"
+		}},
+		{path: []interface{}{"x", "y", 1}, updatingVal: 1, handleErr: func(e error) bool {
+			return e == nil || e.Error() != "Error at No.3 in arguments: index 1 out of range, the len is 0"
+		}},
+		{path: []interface{}{"name", "first"}, updatingVal: 1, handleErr: func(e error) bool {
+			return e == nil || strings.Index(e.Error(), "not a json object") == -1
+		}},
+	}
+	for _, set := range testSet {
+		data := []byte(`{"name": "LBJ", "career": [{"team": "CAVS"}], "empty": {}}`)
+		newData, e := Upsert(data, set.updatingVal, set.path...)
+		if set.handleErr != nil {
+			if set.handleErr(e) {
+				t.Fatal(set.path, e)
+			}
+			continue
+		} else if e != nil {
+			t.Fatal(set.path, e)
+		}
+		if got := strings.TrimRight(string(newData), " "); got != set.expect {
+			t.Logf("%v: Expected %s but got %s", set.path, set.expect, got)
+			t.Fail()
+		}
+		// the value could be got by the path.
+		if set.updatedPath == nil {
+			set.updatedPath = set.path
+		}
+		if val, e := Get(newData, set.updatedPath...); e != nil || !reflect.DeepEqual(val, set.updatingVal) {
+			t.Logf("%v: Expected %v but got %v, %v", set.updatedPath, set.updatingVal, val, e)
+			t.Fail()
+		}
+	}
+}
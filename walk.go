@@ -0,0 +1,76 @@
+package hapijson
+
+// WalkAction tells Walk what to do after visiting a value.
+type WalkAction int8
+
+const (
+	// WalkContinue continues walking, into the value if it's an object or an array.
+	WalkContinue WalkAction = iota
+	// WalkSkip skips the keys or elements of the value, it's the same as WalkContinue if the value is
+	// neither an object nor an array.
+	WalkSkip
+	// WalkStop stops walking.
+	WalkStop
+)
+
+// Walk visits every value in data in the order of the document, the root element first, e.g.
+//
+//	Walk(data, func(path []interface{}, value []byte, kind Kind) WalkAction {
+//		// path is e.g. []interface{}{"career", 2, "team"}, the keys are in string and the indexes are in int,
+//		// it is empty for the root.
+//		if kind == KindObject && len(path) > 0 && path[len(path)-1] == "password" {
+//			return WalkSkip
+//		}
+//		return WalkContinue
+//	})
+//
+// value is the slice of the value in data, it's not copied, the quotes are kept if it's a string.
+// path is reused by the following visits, make a copy of it to keep it.
+// The duplicated keys are visited as they are, DuplicateKeys is not applied.
+//
+// Note: this function assuming data is a valid json data, it doesn't do checking inside.
+func Walk(data []byte, fn func(path []interface{}, value []byte, kind Kind) WalkAction) (e error) {
+	var start, end int
+	var vtype valType
+	if start, end, _, vtype, e = path(data, 0); e != nil {
+		return
+	}
+	_, e = walk(data, make([]interface{}, 0, 8), start, end, vtype, fn)
+	return
+}
+
+// walk visits the value at start and its keys or elements, stop is true if fn returns WalkStop.
+func walk(payload []byte, pathNodes []interface{}, start, end int, vtype valType,
+	fn func(path []interface{}, value []byte, kind Kind) WalkAction) (stop bool, e error) {
+
+	if action := fn(pathNodes, payload[start:end], kindOf(vtype)); action == WalkStop {
+		return true, nil
+	} else if action == WalkSkip || vtype != valObject && vtype != valArray {
+		return
+	}
+	var key string
+	var hasKey, next, empty bool
+	var vStart, vEnd int
+	var childType valType
+	for pos, index := start+1, 0; pos < end; pos, index = pos+1, index+1 {
+		if vtype == valObject {
+			if pos, key, hasKey, e = nextKey(payload, pos, true); e != nil || !hasKey {
+				return
+			}
+		}
+		if pos, vStart, vEnd, childType, next, empty, e = nextValue(payload, pos); e != nil || empty {
+			return
+		}
+		node := interface{}(index)
+		if hasKey {
+			node = key
+		}
+		if stop, e = walk(payload, append(pathNodes, node), vStart, vEnd, childType, fn); stop || e != nil {
+			return
+		}
+		if !next {
+			return
+		}
+	}
+	return false, ErrInvalidJSONPayload
+}
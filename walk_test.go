@@ -0,0 +1,66 @@
+package hapijson
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	data := []byte(`{"name": "LBJ", "career": [{"team": "CAVS"}, {"team": "HEAT", "rings": [2012, 2013]}], "secret": {"pwd": "x"}, "end": null}`)
+	var visited []string
+	if e := Walk(data, func(path []interface{}, value []byte, kind Kind) WalkAction {
+		visited = append(visited, fmt.Sprintf("%v %s %s", path, kind, value))
+		if len(path) > 0 && path[len(path)-1] == "secret" {
+			return WalkSkip
+		}
+		return WalkContinue
+	}); e != nil {
+		t.Fatal(e)
+	}
+	expect := []string{
+		string(append([]byte("[] object "), data...)),
+		"[name] string \"LBJ\"",
+		`[career] array [{"team": "CAVS"}, {"team": "HEAT", "rings": [2012, 2013]}]`,
+		`[career 0] object {"team": "CAVS"}`,
+		`[career 0 team] string "CAVS"`,
+		`[career 1] object {"team": "HEAT", "rings": [2012, 2013]}`,
+		`[career 1 team] string "HEAT"`,
+		`[career 1 rings] array [2012, 2013]`,
+		`[career 1 rings 0] number 2012`,
+		`[career 1 rings 1] number 2013`,
+		`[secret] object {"pwd": "x"}`,
+		`[end] null null`,
+	}
+	if !reflect.DeepEqual(visited, expect) {
+		t.Logf("Expected %q but got %q", expect, visited)
+		t.Fail()
+	}
+
+	var paths [][]interface{}
+	if e := Walk(data, func(path []interface{}, value []byte, kind Kind) WalkAction {
+		paths = append(paths, append([]interface{}{}, path...))
+		if kind == KindNumber {
+			return WalkStop
+		}
+		return WalkContinue
+	}); e != nil {
+		t.Fatal(e)
+	} else if last := paths[len(paths)-1]; len(paths) != 9 || !reflect.DeepEqual(last, []interface{}{"career", 1, "rings", 0}) {
+		t.Fatalf("Expected stopping at [career 1 rings 0] but got %v", paths)
+	}
+	// the path nodes could be used to get the value.
+	for _, p := range paths {
+		if !Exists(data, p...) {
+			t.Fatalf("%v doesn't exist", p)
+		}
+	}
+	if e := Walk([]byte(`[]`), func(path []interface{}, value []byte, kind Kind) WalkAction {
+		if len(path) != 0 {
+			t.Fatalf("Unexpected element %v", path)
+		}
+		return WalkContinue
+	}); e != nil {
+		t.Fatal(e)
+	}
+}